@@ -3,13 +3,12 @@ module github.com/bsponge/discordGopher
 go 1.18
 
 require (
+	github.com/klauspost/compress v1.16.7
 	github.com/sirupsen/logrus v1.9.0
 	github.com/valyala/fastjson v1.6.4
+	golang.org/x/crypto v0.0.0-20220722155217-630584e8d5aa
 	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 	nhooyr.io/websocket v1.8.7
 )
 
-require (
-	github.com/klauspost/compress v1.10.3 // indirect
-	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
-)
+require golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
@@ -0,0 +1,241 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	oggCapturePattern = "OggS"
+	oggHeaderSize     = 27
+	oggMaxSegmentSize = 255
+
+	oggContinuedPacket = 0x01
+	oggEndOfStream     = 0x04
+
+	opusHeadMagic   = "OpusHead"
+	opusTagsMagic   = "OpusTags"
+	opusHeadMinSize = 19
+	opusSampleRate  = 48000
+)
+
+// OggOpusSource demuxes an Ogg container carrying a single Opus stream into raw Opus packets.
+type OggOpusSource struct {
+	reader io.Reader
+	closer io.Closer
+
+	serial    uint32
+	hasSerial bool
+	eos       bool
+
+	channels uint8
+	preSkip  uint16
+	granule  int64
+
+	packets [][]byte
+	partial []byte
+}
+
+func OpenOggOpusFile(path string) (*OggOpusSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := NewOggOpusSource(bufio.NewReader(file))
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("could not open %s: %w", path, err)
+	}
+
+	source.closer = file
+
+	return source, nil
+}
+
+// NewOggOpusSource reads the OpusHead and OpusTags headers and returns a source positioned at the first audio packet.
+func NewOggOpusSource(reader io.Reader) (*OggOpusSource, error) {
+	source := &OggOpusSource{
+		reader: reader,
+	}
+
+	err := source.readHeaders()
+	if err != nil {
+		return nil, err
+	}
+
+	return source, nil
+}
+
+func (s *OggOpusSource) NextFrame() ([]byte, error) {
+	packet, err := s.nextPacket()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = PacketDuration(packet)
+	if err != nil {
+		return nil, err
+	}
+
+	return packet, nil
+}
+
+// Channels returns the channel count declared in the OpusHead header.
+func (s *OggOpusSource) Channels() int {
+	return int(s.channels)
+}
+
+// Position returns the playback position of the last completed page, based on its granule position.
+func (s *OggOpusSource) Position() time.Duration {
+	samples := s.granule - int64(s.preSkip)
+	if samples < 0 {
+		return 0
+	}
+
+	return time.Duration(samples) * time.Second / opusSampleRate
+}
+
+func (s *OggOpusSource) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+
+	return s.closer.Close()
+}
+
+func (s *OggOpusSource) readHeaders() error {
+	head, err := s.nextPacket()
+	if err != nil {
+		return fmt.Errorf("could not read OpusHead header: %w", err)
+	}
+
+	if len(head) < opusHeadMinSize || string(head[:len(opusHeadMagic)]) != opusHeadMagic {
+		return fmt.Errorf("stream does not start with an OpusHead header")
+	}
+
+	// Only the major version (upper four bits) has to match, minor versions are backwards compatible.
+	if version := head[8]; version>>4 != 0 {
+		return fmt.Errorf("unsupported OpusHead version %d", version)
+	}
+
+	s.channels = head[9]
+	s.preSkip = binary.LittleEndian.Uint16(head[10:12])
+
+	tags, err := s.nextPacket()
+	if err != nil {
+		return fmt.Errorf("could not read OpusTags header: %w", err)
+	}
+
+	if !bytes.HasPrefix(tags, []byte(opusTagsMagic)) {
+		return fmt.Errorf("OpusHead header is not followed by OpusTags header")
+	}
+
+	return nil
+}
+
+func (s *OggOpusSource) nextPacket() ([]byte, error) {
+	for len(s.packets) == 0 {
+		if s.eos {
+			return nil, io.EOF
+		}
+
+		err := s.readPage()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	packet := s.packets[0]
+	s.packets = s.packets[1:]
+
+	return packet, nil
+}
+
+func (s *OggOpusSource) readPage() error {
+	var header [oggHeaderSize]byte
+	_, err := io.ReadFull(s.reader, header[:])
+	if err != nil {
+		return err
+	}
+
+	if string(header[0:4]) != oggCapturePattern {
+		return fmt.Errorf("invalid ogg page capture pattern")
+	}
+
+	if version := header[4]; version != 0 {
+		return fmt.Errorf("unsupported ogg page version %d", version)
+	}
+
+	headerType := header[5]
+	granule := int64(binary.LittleEndian.Uint64(header[6:14]))
+	serial := binary.LittleEndian.Uint32(header[14:18])
+
+	lacing := make([]byte, header[26])
+	_, err = io.ReadFull(s.reader, lacing)
+	if err != nil {
+		return err
+	}
+
+	var bodySize int
+	for _, segmentSize := range lacing {
+		bodySize += int(segmentSize)
+	}
+
+	body := make([]byte, bodySize)
+	_, err = io.ReadFull(s.reader, body)
+	if err != nil {
+		return err
+	}
+
+	if !s.hasSerial {
+		s.serial = serial
+		s.hasSerial = true
+	} else if serial != s.serial {
+		// Pages of other logical streams are skipped.
+		return nil
+	}
+
+	continued := headerType&oggContinuedPacket != 0
+	if !continued {
+		s.partial = nil
+	}
+
+	// A continuation without the beginning of the packet (e.g. a lost page) cannot be decoded.
+	skipping := continued && s.partial == nil
+
+	var offset int
+	for _, segmentSize := range lacing {
+		segment := body[offset : offset+int(segmentSize)]
+		offset += int(segmentSize)
+
+		if !skipping {
+			s.partial = append(s.partial, segment...)
+		}
+
+		if segmentSize < oggMaxSegmentSize {
+			if !skipping {
+				s.packets = append(s.packets, s.partial)
+			}
+
+			s.partial = nil
+			skipping = false
+		}
+	}
+
+	// -1 means that no packet finishes on this page.
+	if granule != -1 {
+		s.granule = granule
+	}
+
+	if headerType&oggEndOfStream != 0 {
+		s.eos = true
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testSerial = 0x5eed
+
+// oggPage builds an Ogg page with the given lacing values and body. The demuxer does not check the CRC,
+// so it is left empty.
+func oggPage(headerType byte, granule int64, serial uint32, lacing []byte, body []byte) []byte {
+	header := make([]byte, oggHeaderSize)
+	copy(header, oggCapturePattern)
+	header[5] = headerType
+	binary.LittleEndian.PutUint64(header[6:14], uint64(granule))
+	binary.LittleEndian.PutUint32(header[14:18], serial)
+	header[26] = byte(len(lacing))
+
+	page := append(header, lacing...)
+
+	return append(page, body...)
+}
+
+// packetLacing returns the lacing values of the packets put one after another on a single page.
+func packetLacing(packets ...[]byte) []byte {
+	var lacing []byte
+	for _, packet := range packets {
+		for n := len(packet); ; n -= oggMaxSegmentSize {
+			if n < oggMaxSegmentSize {
+				lacing = append(lacing, byte(n))
+				break
+			}
+
+			lacing = append(lacing, oggMaxSegmentSize)
+		}
+	}
+
+	return lacing
+}
+
+func packetsPage(headerType byte, granule int64, packets ...[]byte) []byte {
+	return oggPage(headerType, granule, testSerial, packetLacing(packets...), bytes.Join(packets, nil))
+}
+
+// opusHeaders returns the OpusHead and OpusTags pages of a stereo stream.
+func opusHeaders(preSkip uint16) []byte {
+	head := make([]byte, opusHeadMinSize)
+	copy(head, opusHeadMagic)
+	head[8] = 1
+	head[9] = 2
+	binary.LittleEndian.PutUint16(head[10:12], preSkip)
+	binary.LittleEndian.PutUint32(head[12:16], opusSampleRate)
+
+	tags := append([]byte(opusTagsMagic), 0x0d, 0, 0, 0)
+	tags = append(tags, "discordGopher"...)
+	tags = append(tags, 0, 0, 0, 0)
+
+	return append(packetsPage(0x02, 0, head), packetsPage(0, 0, tags)...)
+}
+
+// opusPacket returns a 20 ms CELT packet of the given size.
+func opusPacket(size int, fill byte) []byte {
+	packet := bytes.Repeat([]byte{fill}, size)
+	packet[0] = 0xf8
+
+	return packet
+}
+
+func TestOggOpusSourceSkipsHeaders(t *testing.T) {
+	first := opusPacket(3, 0x01)
+	second := opusPacket(60, 0x02)
+
+	stream := append(opusHeaders(312), packetsPage(oggEndOfStream, 312+2*960, first, second)...)
+
+	source, err := NewOggOpusSource(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source.Channels() != 2 || source.preSkip != 312 {
+		t.Fatalf("got %d channels and pre-skip %d, want 2 and 312", source.Channels(), source.preSkip)
+	}
+
+	for _, want := range [][]byte{first, second} {
+		frame, err := source.NextFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(frame, want) {
+			t.Fatalf("got frame % x, want % x", frame, want)
+		}
+	}
+
+	_, err = source.NextFrame()
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("got error %v after the last page, want EOF", err)
+	}
+}
+
+func TestOggOpusSourceRejectsMissingHeaders(t *testing.T) {
+	tests := map[string][]byte{
+		"no OpusHead": packetsPage(0x02, 0, opusPacket(3, 0x01)),
+		"no OpusTags": append(opusHeaders(0)[:oggHeaderSize+1+opusHeadMinSize], packetsPage(0, 0, opusPacket(3, 0x01))...),
+	}
+
+	for name, stream := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewOggOpusSource(bytes.NewReader(stream))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestOggOpusSourcePacketAcrossPages(t *testing.T) {
+	long := opusPacket(700, 0x03)
+	short := opusPacket(3, 0x04)
+
+	// The long packet fills the first page, no packet finishes on it, so its granule position is -1.
+	stream := opusHeaders(0)
+	stream = append(stream, oggPage(0, -1, testSerial, []byte{255, 255}, long[:510])...)
+	stream = append(stream, oggPage(oggContinuedPacket|oggEndOfStream, 2*960, testSerial,
+		[]byte{190, 3}, append(long[510:], short...))...)
+
+	source, err := NewOggOpusSource(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var frames [][]byte
+	for {
+		frame, err := source.NextFrame()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		frames = append(frames, frame)
+	}
+
+	if !reflect.DeepEqual(frames, [][]byte{long, short}) {
+		t.Fatalf("got %d frames of sizes %v, want the joined packet followed by the short one", len(frames), frameSizes(frames))
+	}
+}
+
+func TestOggOpusSourceSkipsOrphanedContinuation(t *testing.T) {
+	packet := opusPacket(3, 0x05)
+
+	// The page carrying the beginning of the first packet was lost.
+	stream := opusHeaders(0)
+	stream = append(stream, oggPage(oggContinuedPacket|oggEndOfStream, 960, testSerial,
+		append([]byte{100}, packetLacing(packet)...), append(bytes.Repeat([]byte{0x06}, 100), packet...))...)
+
+	source, err := NewOggOpusSource(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frame, err := source.NextFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(frame, packet) {
+		t.Fatalf("got frame % x, want % x", frame, packet)
+	}
+}
+
+func TestOggOpusSourceSkipsOtherStreams(t *testing.T) {
+	packet := opusPacket(3, 0x07)
+
+	stream := opusHeaders(0)
+	stream = append(stream, oggPage(0, 960, testSerial+1, packetLacing(opusPacket(5, 0x08)), opusPacket(5, 0x08))...)
+	stream = append(stream, packetsPage(oggEndOfStream, 960, packet)...)
+
+	source, err := NewOggOpusSource(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frame, err := source.NextFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(frame, packet) {
+		t.Fatalf("got frame % x, want % x", frame, packet)
+	}
+}
+
+func TestOggOpusSourcePosition(t *testing.T) {
+	stream := opusHeaders(312)
+	stream = append(stream, packetsPage(0, 312+2*960, opusPacket(3, 0x01), opusPacket(3, 0x02))...)
+	stream = append(stream, oggPage(0, -1, testSerial, []byte{255}, opusPacket(255, 0x03))...)
+	stream = append(stream, oggPage(oggContinuedPacket|oggEndOfStream, 312+3*960, testSerial, []byte{10}, opusPacket(10, 0x03))...)
+
+	source, err := NewOggOpusSource(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source.Position() != 0 {
+		t.Fatalf("got position %s before the first audio page, want 0", source.Position())
+	}
+
+	// The position is the one of the last page read, the page without a finished packet does not change it.
+	want := []time.Duration{40 * time.Millisecond, 40 * time.Millisecond, 60 * time.Millisecond}
+	for i, position := range want {
+		_, err := source.NextFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if source.Position() != position {
+			t.Fatalf("got position %s after frame %d, want %s", source.Position(), i, position)
+		}
+	}
+}
+
+func frameSizes(frames [][]byte) []int {
+	sizes := make([]int, len(frames))
+	for i, frame := range frames {
+		sizes[i] = len(frame)
+	}
+
+	return sizes
+}
@@ -0,0 +1,66 @@
+package audio
+
+import (
+	"fmt"
+	"time"
+)
+
+const opusMaxPacketDuration = 120 * time.Millisecond
+
+// opusFrameDurations maps the configuration number from the TOC byte to the duration of a single frame,
+// see RFC 6716 section 3.1.
+var opusFrameDurations = [32]time.Duration{
+	// SILK-only
+	10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 60 * time.Millisecond,
+	10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 60 * time.Millisecond,
+	10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 60 * time.Millisecond,
+	// Hybrid
+	10 * time.Millisecond, 20 * time.Millisecond,
+	10 * time.Millisecond, 20 * time.Millisecond,
+	// CELT-only
+	2500 * time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond,
+	2500 * time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond,
+	2500 * time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond,
+	2500 * time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond,
+}
+
+// PacketDuration returns the duration of the audio in the Opus packet, i.e. the duration of its frames
+// times their count, both read from the TOC byte.
+func PacketDuration(packet []byte) (time.Duration, error) {
+	if len(packet) == 0 {
+		return 0, fmt.Errorf("empty opus packet")
+	}
+
+	toc := packet[0]
+	frameDuration := opusFrameDurations[toc>>3]
+
+	var frames int
+	switch toc & 0x03 {
+	case 0:
+		frames = 1
+	case 1, 2:
+		frames = 2
+	default:
+		if len(packet) < 2 {
+			return 0, fmt.Errorf("opus packet with an arbitrary number of frames has no frame count")
+		}
+		frames = int(packet[1] & 0x3f)
+	}
+
+	duration := time.Duration(frames) * frameDuration
+	if frames == 0 || duration > opusMaxPacketDuration {
+		return 0, fmt.Errorf("invalid opus packet with %d frames of %s", frames, frameDuration)
+	}
+
+	return duration, nil
+}
+
+// PacketSamples returns the number of 48 kHz samples in the Opus packet, used to advance RTP timestamps.
+func PacketSamples(packet []byte) (uint32, error) {
+	duration, err := PacketDuration(packet)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint32(duration * opusSampleRate / time.Second), nil
+}
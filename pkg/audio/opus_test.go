@@ -0,0 +1,59 @@
+package audio
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPacketDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		packet   []byte
+		duration time.Duration
+		samples  uint32
+	}{
+		{name: "celt 20 ms", packet: []byte{0xf8, 0xff, 0xfe}, duration: 20 * time.Millisecond, samples: 960},
+		{name: "celt 2.5 ms", packet: []byte{0x80}, duration: 2500 * time.Microsecond, samples: 120},
+		{name: "celt 10 ms", packet: []byte{0x90}, duration: 10 * time.Millisecond, samples: 480},
+		{name: "silk 60 ms", packet: []byte{0x18}, duration: 60 * time.Millisecond, samples: 2880},
+		{name: "hybrid two 10 ms frames", packet: []byte{0x61}, duration: 20 * time.Millisecond, samples: 960},
+		{name: "silk three 40 ms frames", packet: []byte{0x13, 0x03}, duration: 120 * time.Millisecond, samples: 5760},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			duration, err := PacketDuration(test.packet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if duration != test.duration {
+				t.Fatalf("got duration %s, want %s", duration, test.duration)
+			}
+
+			samples, err := PacketSamples(test.packet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if samples != test.samples {
+				t.Fatalf("got %d samples, want %d", samples, test.samples)
+			}
+		})
+	}
+}
+
+func TestPacketDurationInvalid(t *testing.T) {
+	packets := [][]byte{
+		{},
+		{0x03},
+		{0x03, 0x00},
+		// Three 60 ms frames exceed the 120 ms limit.
+		{0x1b, 0x03},
+	}
+
+	for _, packet := range packets {
+		_, err := PacketDuration(packet)
+		if err == nil {
+			t.Fatalf("expected an error for packet %x", packet)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package audio
+
+// Source provides raw Opus packets of 48 kHz audio, ready to be sent to a voice channel. A packet may hold up to
+// 120 ms of audio, PacketDuration returns how much.
+type Source interface {
+	// NextFrame returns the next Opus packet or io.EOF once the source is exhausted.
+	NextFrame() ([]byte, error)
+	Close() error
+}
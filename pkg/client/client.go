@@ -2,24 +2,17 @@ package client
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
 	"regexp"
-	"runtime"
 	"strings"
 	"sync"
 
 	"github.com/bsponge/discordGopher/pkg/config"
+	"github.com/bsponge/discordGopher/pkg/interaction"
 	"github.com/bsponge/discordGopher/pkg/log"
 	"github.com/bsponge/discordGopher/pkg/object"
-
-	"github.com/valyala/fastjson"
-	"nhooyr.io/websocket"
-	"nhooyr.io/websocket/wsjson"
+	"github.com/bsponge/discordGopher/pkg/rest"
+	"github.com/bsponge/discordGopher/pkg/state"
 )
 
 const (
@@ -27,42 +20,36 @@ const (
 	encodingKey   = "encoding"
 
 	apiVersionValue = "10"
-	encodingValue   = "json"
 
 	tokenURL       = "https://discord.com/api/oauth2/token"
 	oauth2TokenURL = "https://discord.com/api/oauth2/token"
-	apiEndpoint    = "https://discord.com/api/v10"
 
-	playCommand = "play"
+	gatewayReadLimit = 64 << 20
 )
 
 var mentionRegex = regexp.MustCompile("<@.*>")
 
 type Client struct {
 	parentCtx context.Context
-	ctx       context.Context
-	cancel    context.CancelFunc
 
 	mtx sync.Mutex
 
-	cfg *config.Config
-
-	sequence int
-
-	gatewayWebsocket *websocket.Conn
-	resumeGatewayURL *url.URL
-	sessionID        string
-	guildID          string
-	userID           string
+	cfg   *config.Config
+	rest  *rest.Client
+	codec gatewayCodec
 
-	hbService *heartbeatService
+	shards *shardManager
+	userID string
 
-	voiceStates                  map[string]object.VoiceState
-	voiceServerInformationWaitCh chan struct{}
+	state        *state.State
+	events       *eventRegistry
+	interactions *interaction.Router
+	library      *trackLibrary
 
-	voiceClient *voiceClient
+	commandsSync sync.Once
 
-	guild *object.Guild
+	players  map[string]*player
+	commands map[string]*commandQueue
 }
 
 func NewClient() (*Client, error) {
@@ -71,163 +58,117 @@ func NewClient() (*Client, error) {
 		return nil, err
 	}
 
-	client := &Client{
-		cfg: cfg,
+	cacheFlags, err := state.ParseCacheFlags(cfg.Cache)
+	if err != nil {
+		return nil, err
 	}
 
-	hbService := NewHeartbeatService(client)
-	client.hbService = hbService
-
-	return client, nil
-}
-
-func (c *Client) Start(ctx context.Context) error {
-	c.parentCtx = ctx
-
-	log.Logger().Info("Starting the client")
-
-	if err := c.start(ctx, false); err != nil {
-		return err
+	var restOpts []rest.Option
+	if cfg.APIURL != "" {
+		restOpts = append(restOpts, rest.WithBaseURL(cfg.APIURL))
 	}
 
-	log.Logger().Info("The clietn has started successfully")
-
-	return nil
-}
+	codec, err := newGatewayCodec(cfg.Encoding)
+	if err != nil {
+		return nil, err
+	}
 
-func (c *Client) start(ctx context.Context, reconnecting bool) error {
-	c.ctx, c.cancel = context.WithCancel(ctx)
+	restClient := rest.NewClient(cfg.Token, restOpts...)
 
-	c.voiceStates = make(map[string]object.VoiceState)
+	client := &Client{
+		cfg:          cfg,
+		rest:         restClient,
+		codec:        codec,
+		state:        state.New(cacheFlags, codec.unmarshal),
+		events:       newEventRegistry(codec.unmarshal),
+		interactions: interaction.NewRouter(restClient),
+		players:      make(map[string]*player),
+		commands:     make(map[string]*commandQueue),
+	}
 
-	gatewayURL, err := c.getGatewayURL()
-	if err != nil {
-		return err
+	if cfg.MusicDir != "" {
+		client.library = newTrackLibrary(cfg.MusicDir)
 	}
 
-	log.Logger().Infof("Gateway URL: %s", gatewayURL)
+	client.registerPlayerCommands()
+	client.registerPlayerControls()
 
-	ws, _, err := websocket.Dial(c.ctx, gatewayURL, nil)
-	if err != nil {
-		return err
-	}
+	client.shards = newShardManager(client)
 
-	c.gatewayWebsocket = ws
+	return client, nil
+}
 
-	if reconnecting {
-		event := object.Event[object.Resume]{
-			Op: 6,
-			D: object.Resume{
-				Token:     c.cfg.Token,
-				SessionID: c.sessionID,
-				Sequence:  c.GetSequence(),
-			},
-		}
+func (c *Client) Start(ctx context.Context) error {
+	c.parentCtx = ctx
+
+	log.Logger().Info("Starting the client")
 
-		err := wsjson.Write(c.ctx, ws, event)
+	if c.cfg.InteractionsAddress != "" {
+		err := c.startInteractionsServer(ctx)
 		if err != nil {
 			return err
 		}
 	}
 
-	go c.poolMessages(reconnecting)
+	if err := c.shards.start(ctx, c.cfg.Shards); err != nil {
+		return err
+	}
+
+	log.Logger().Info("The clietn has started successfully")
 
 	return nil
 }
 
-func (c *Client) GetSequence() int {
-	c.mtx.Lock()
-	defer c.mtx.Unlock()
-
-	return c.sequence
+// REST returns the client of the Discord HTTP API used by the bot.
+func (c *Client) REST() *rest.Client {
+	return c.rest
 }
 
-func (c *Client) setSequence(sequence int) {
-	c.mtx.Lock()
-	defer c.mtx.Unlock()
-
-	c.sequence = sequence
+// State returns the cache of the entities received from the gateway.
+func (c *Client) State() *state.State {
+	return c.state
 }
 
-func (c *Client) poolMessages(resuming bool) {
-	for {
-		_, body, err := c.gatewayWebsocket.Read(c.ctx)
-		var closeError websocket.CloseError
-		switch {
-		case errors.As(err, &closeError):
-			log.Logger().WithError(err).Error("The websocket connection was closed")
-			shouldReconnect, ok := object.ReconnectOnError[int(closeError.Code)]
-			if !ok || !shouldReconnect {
-				return
-			}
-
-			c.resumeConnection()
-			return
-		case err != nil:
-			log.Logger().WithError(err).Error("Could not read message from gateway wss")
-			return
-		default:
-		}
-
-		log.Logger().Trace(string(body))
-
-		resp, err := fastjson.ParseBytes(body)
-		if err != nil {
-			log.Logger().WithError(err).Error("Could not parse json received from gateway wss")
-			return
-		}
+// Interactions returns the router of the slash commands and other interactions.
+func (c *Client) Interactions() *interaction.Router {
+	return c.interactions
+}
 
-		c.setSequence(resp.GetInt("s"))
-		op := resp.GetInt("op")
-
-		switch op {
-		case 0: // Dispatch (most Gateway events which represent actions taking place in a guild)
-			dispatch := string(resp.GetStringBytes("t"))
-			var d []byte
-			d = resp.GetObject("d").MarshalTo(d)
-			c.handleDispatch(object.Dispatch(dispatch), d)
-		case 1: // Extra heartbeat
-			err := c.hbService.SendHeartbeat(c.gatewayWebsocket)
-			if err != nil {
-				log.Logger().WithError(err).Error("Could not send heartbeat after receiving op code 1")
-			}
-		case 7: // Reconnect
-			c.resumeConnection()
-			return
-		case 9: // Invalid session
-			if resp.GetBool("d") {
-				c.resumeConnection()
-				return
-			}
-		case 10: // Hello
-			heartbeatInterval := resp.Get("d").GetInt("heartbeat_interval")
-			err := c.hbService.Start(c.ctx, c.gatewayWebsocket, heartbeatInterval, resuming)
-			if err != nil {
-				log.Logger().WithError(err).Error("Could not send first heartbeat")
-				return
-			}
-		case 11: // Heartbeat ACK
-			log.Logger().Trace("Received heartbeat ACK")
-		default:
-			log.Logger().Trace("Unknown op code")
-		}
-	}
+// Shards returns the status of every gateway shard.
+func (c *Client) Shards() []ShardStatus {
+	return c.shards.statuses()
 }
 
 func (c *Client) handleDispatch(dispatch object.Dispatch, payload []byte) error {
+	err := c.state.Ingest(dispatch, payload)
+	if err != nil {
+		return err
+	}
+
+	subscribers := c.events.dispatch(c.parentCtx, dispatch, payload)
+
 	switch dispatch {
 	case object.ReadyType:
 		return c.handleReady(payload)
-	case object.GuildCreateType:
-		return c.handleGuildCreate(payload)
 	case object.MessageCreateType:
 		return c.handleMessageCreate(payload)
 	case object.VoiceStateUpdateType:
 		return c.handleVoiceStateUpdate(payload)
 	case object.VoiceServerUpdateType:
 		return c.handleVoiceServerUpdate(payload)
+	case object.InteractionCreateType:
+		return c.handleInteractionCreate(payload)
+	case object.GuildCreateType, object.GuildUpdateType, object.GuildDeleteType,
+		object.ChannelCreateType, object.ChannelUpdateType, object.ChannelDeleteType,
+		object.GuildMemberAddType, object.GuildMemberUpdateType, object.GuildMemberRemoveType,
+		object.GuildRoleCreateType, object.GuildRoleUpdateType, object.GuildRoleDeleteType:
+		// Handled by the state cache only.
+	case object.ResumedType:
+		// Handled by the shard.
 	default:
-		log.Logger().WithField("dispatch_type", dispatch).Warn("Received unknown dispatch")
+		if subscribers == 0 {
+			log.Logger().WithField("dispatch_type", dispatch).Warn("Received unknown dispatch")
+		}
 	}
 
 	return nil
@@ -235,48 +176,34 @@ func (c *Client) handleDispatch(dispatch object.Dispatch, payload []byte) error
 
 func (c *Client) handleReady(payload []byte) error {
 	var ready object.Ready
-	err := json.Unmarshal(payload, &ready)
-	if err != nil {
-		return err
-	}
-
-	resumeURL, err := url.Parse(ready.ResumeGatewayURL)
+	err := c.codec.unmarshal(payload, &ready)
 	if err != nil {
 		return err
 	}
 
-	c.resumeGatewayURL = resumeURL
-	c.sessionID = ready.SessionID
-	c.guildID = ready.Guilds[0].ID
+	c.mtx.Lock()
 	c.userID = ready.User.ID
+	c.mtx.Unlock()
 
-	return nil
-}
-
-func (c *Client) handleGuildCreate(payload []byte) error {
-	var guild object.Guild
-	err := json.Unmarshal(payload, &guild)
-	if err != nil {
-		return err
-	}
-
-	c.setGuild(&guild)
-	if guild.VoiceStates != nil {
-		for _, voiceState := range *guild.VoiceStates {
-			c.voiceStates[voiceState.UserID] = voiceState
-		}
-	}
+	c.commandsSync.Do(func() {
+		go c.syncPlayerCommands()
+	})
 
 	return nil
 }
 
 func (c *Client) handleMessageCreate(payload []byte) error {
 	var message object.Message
-	err := json.Unmarshal(payload, &message)
+	err := c.codec.unmarshal(payload, &message)
 	if err != nil {
 		return err
 	}
 
+	// Bots, including this one, are ignored so that replies never trigger commands.
+	if message.Author == nil || (message.Author.Bot != nil && *message.Author.Bot) {
+		return nil
+	}
+
 	if message.Content != nil {
 		content := mentionRegex.ReplaceAllString(*message.Content, "")
 		words := strings.Split(content, " ")
@@ -288,30 +215,26 @@ func (c *Client) handleMessageCreate(payload []byte) error {
 				continue
 			}
 
-			filteredWords = append(filteredWords, strings.ToLower(word))
+			filteredWords = append(filteredWords, word)
 		}
 
 		if len(filteredWords) == 0 {
 			return nil
 		}
 
-		command := filteredWords[0]
-
-		switch command {
-		case playCommand:
-			c.voiceClient = NewVoiceClient(c.ctx, c)
-
-			voiceState, ok := c.voiceStates[message.Author.ID]
-			if !ok {
-				return fmt.Errorf("could not find voice state information for user %s", message.Author.Username)
-			}
+		guildID, ok := c.resolveGuildID(&message)
+		if !ok {
+			return nil
+		}
 
-			log.Logger().Info("VOICESTATE ", voiceState)
+		command := strings.ToLower(filteredWords[0])
+		args := filteredWords[1:]
 
-			c.voiceClient.ConnectToVoiceChannel(c.guildID, *voiceState.ChannelID, false, false)
-		default:
-			log.Logger().WithField("command", command).WithField("user", message.Author.Username).Info("User used unknown command")
-		}
+		// Commands talk to the REST API, so they must not block the gateway read loop. The commands of a guild
+		// still run in the order they were sent, e.g. two plays are queued in that order.
+		c.getCommandQueue(guildID).push(func() {
+			c.handleCommand(guildID, &message, command, args)
+		})
 	}
 
 	return nil
@@ -319,15 +242,22 @@ func (c *Client) handleMessageCreate(payload []byte) error {
 
 func (c *Client) handleVoiceStateUpdate(payload []byte) error {
 	var voiceState object.VoiceState
-	err := json.Unmarshal(payload, &voiceState)
+	err := c.codec.unmarshal(payload, &voiceState)
 	if err != nil {
 		return err
 	}
 
-	c.voiceStates[voiceState.UserID] = voiceState
-
-	if voiceState.UserID == c.userID {
-		c.voiceServerInformationWaitCh <- struct{}{}
+	c.mtx.Lock()
+	userID := c.userID
+	c.mtx.Unlock()
+
+	if voiceState.UserID == userID && voiceState.GuildID != nil {
+		if voiceClient := c.getVoiceClient(*voiceState.GuildID); voiceClient != nil {
+			select {
+			case voiceClient.GetVoiceStateCh() <- voiceState:
+			default:
+			}
+		}
 	}
 
 	return nil
@@ -335,122 +265,90 @@ func (c *Client) handleVoiceStateUpdate(payload []byte) error {
 
 func (c *Client) handleVoiceServerUpdate(payload []byte) error {
 	var voiceServerUpdate object.VoiceServerUpdate
-	err := json.Unmarshal(payload, &voiceServerUpdate)
+	err := c.codec.unmarshal(payload, &voiceServerUpdate)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
-
-func (c *Client) resumeConnection() {
-	c.Stop()
-	c.ctx, c.cancel = context.WithCancel(c.parentCtx)
-
-	log.Logger().Info("Reconnecting...")
-
-	err := c.start(c.ctx, true)
-	if err != nil {
-		log.Logger().WithError(err).Error("Could not resume connection")
+	if voiceClient := c.getVoiceClient(voiceServerUpdate.GuildID); voiceClient != nil {
+		select {
+		case voiceClient.GetVoiceServerUpdateCh() <- voiceServerUpdate:
+		default:
+		}
 	}
+
+	return nil
 }
 
-func (c *Client) getGuild() *object.Guild {
+func (c *Client) getPlayer(guildID string) *player {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
-	return c.guild
+	p, ok := c.players[guildID]
+	if !ok {
+		p = newPlayer(c.parentCtx, c, guildID)
+		c.players[guildID] = p
+	}
+
+	return p
 }
 
-func (c *Client) setGuild(guild *object.Guild) {
+func (c *Client) getCommandQueue(guildID string) *commandQueue {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
-	c.guild = guild
-}
-
-func (c *Client) Identify() error {
-	var intent int = 1         // GUILDS
-	intent = intent | (1 << 1) // GUILD_MEMBERS
-	intent = intent | (1 << 7) // GUILD_VOICE_STATES
-	intent = intent | (1 << 8) // GUILD_PRESENCES
-	intent = intent | (1 << 9) // GUILD_MESSAGES
-
-	identify := object.Identify{
-		Token: c.cfg.Token,
-		Properties: map[string]any{
-			"os":      runtime.GOOS,
-			"browser": "discordGopher",
-			"device":  "discordGopher",
-		},
-		Compress: false,
-		Intents:  intent,
-	}
-
-	event := object.Event[object.Identify]{
-		Op: 2,
-		D:  identify,
-	}
-
-	err := wsjson.Write(c.ctx, c.gatewayWebsocket, event)
-	if err != nil {
-		return err
+	q, ok := c.commands[guildID]
+	if !ok {
+		q = &commandQueue{}
+		c.commands[guildID] = q
 	}
 
-	return nil
+	return q
 }
 
-// getGateway gets gateway WSS URL which is used to listen for discord server events.
-func (c *Client) getGatewayURL() (string, error) {
-	if c.resumeGatewayURL != nil {
-		return c.resumeGatewayURL.String(), nil
-	}
+func (c *Client) getVoiceClient(guildID string) *voiceClient {
+	c.mtx.Lock()
+	p, ok := c.players[guildID]
+	c.mtx.Unlock()
 
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/gateway/bot", apiEndpoint), nil)
-	if err != nil {
-		return "", err
+	if !ok {
+		return nil
 	}
 
-	req.Header.Add("Authorization", fmt.Sprintf("Bot %s", c.cfg.Token))
+	return p.GetVoiceClient()
+}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", err
+// resolveGuildID returns the ID of the guild the message was sent in.
+func (c *Client) resolveGuildID(message *object.Message) (string, bool) {
+	if message.GuildID != nil {
+		return *message.GuildID, true
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+	channel, ok := c.state.Channel(message.ChannelID)
+	if !ok || channel.GuildID == nil {
+		return "", false
 	}
 
-	gatewayURL := fastjson.GetString(body, "url")
-	if gatewayURL == "" {
-		return "", fmt.Errorf("could not obtain gateway url from received response")
-	}
+	return *channel.GuildID, true
+}
 
-	url, err := url.Parse(gatewayURL)
-	if err != nil {
-		return "", err
+// updateVoiceState sends the voice state update over the shard of the guild.
+func (c *Client) updateVoiceState(ctx context.Context, update object.VoiceStateUpdate) error {
+	s := c.shards.shardFor(update.GuildID)
+	if s == nil {
+		return fmt.Errorf("the client is not connected to the gateway")
 	}
 
-	values := url.Query()
-
-	values.Add(apiVersionKey, apiVersionValue)
-	values.Add(encodingKey, encodingValue)
-
-	url.RawQuery = values.Encode()
-
-	return url.String(), nil
+	return s.send(ctx, object.Event[object.VoiceStateUpdate]{
+		Op: 4,
+		D:  update,
+	})
 }
 
 func (c *Client) Stop() {
 	log.Logger().Info("Stopping the client")
 
-	c.gatewayWebsocket.Close(websocket.StatusInternalError, "")
-	c.cancel()
-
-	c.hbService.Stop()
+	c.shards.stop()
 
 	log.Logger().Info("The client has stopped")
 }
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/bsponge/discordGopher/pkg/etf"
+
+	"github.com/valyala/fastjson"
+	"nhooyr.io/websocket"
+)
+
+const (
+	jsonEncoding = "json"
+	etfEncoding  = "etf"
+)
+
+// gatewayMessage is a message received from the gateway, data is the d field still encoded with the codec.
+type gatewayMessage struct {
+	op       int
+	sequence *int
+	dispatch string
+	data     []byte
+}
+
+// gatewayCodec encodes the commands sent to the gateway and decodes the messages received from it.
+type gatewayCodec interface {
+	encoding() string
+	messageType() websocket.MessageType
+	marshal(v any) ([]byte, error)
+	unmarshal(data []byte, v any) error
+	decode(message []byte) (gatewayMessage, error)
+}
+
+func newGatewayCodec(encoding string) (gatewayCodec, error) {
+	switch encoding {
+	case "", jsonEncoding:
+		return jsonCodec{}, nil
+	case etfEncoding:
+		return etfCodec{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported gateway encoding %q", encoding)
+	}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) encoding() string {
+	return jsonEncoding
+}
+
+func (jsonCodec) messageType() websocket.MessageType {
+	return websocket.MessageText
+}
+
+func (jsonCodec) marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) decode(message []byte) (gatewayMessage, error) {
+	resp, err := fastjson.ParseBytes(message)
+	if err != nil {
+		return gatewayMessage{}, err
+	}
+
+	decoded := gatewayMessage{
+		op:       resp.GetInt("op"),
+		dispatch: string(resp.GetStringBytes("t")),
+	}
+
+	if s := resp.Get("s"); s != nil && s.Type() == fastjson.TypeNumber {
+		sequence := s.GetInt()
+		decoded.sequence = &sequence
+	}
+
+	if d := resp.Get("d"); d != nil {
+		decoded.data = d.MarshalTo(nil)
+	}
+
+	return decoded, nil
+}
+
+type etfCodec struct{}
+
+type etfMessage struct {
+	Op       int         `json:"op"`
+	Sequence *int        `json:"s"`
+	Dispatch string      `json:"t"`
+	Data     etf.RawTerm `json:"d"`
+}
+
+func (etfCodec) encoding() string {
+	return etfEncoding
+}
+
+func (etfCodec) messageType() websocket.MessageType {
+	return websocket.MessageBinary
+}
+
+func (etfCodec) marshal(v any) ([]byte, error) {
+	return etf.Marshal(v)
+}
+
+func (etfCodec) unmarshal(data []byte, v any) error {
+	return etf.Unmarshal(data, v)
+}
+
+func (etfCodec) decode(message []byte) (gatewayMessage, error) {
+	var decoded etfMessage
+	err := etf.Unmarshal(message, &decoded)
+	if err != nil {
+		return gatewayMessage{}, err
+	}
+
+	return gatewayMessage{
+		op:       decoded.Op,
+		sequence: decoded.Sequence,
+		dispatch: decoded.Dispatch,
+		data:     decoded.Data,
+	}, nil
+}
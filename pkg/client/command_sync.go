@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/bsponge/discordGopher/pkg/log"
+	"github.com/bsponge/discordGopher/pkg/object"
+)
+
+type commandKey struct {
+	name        string
+	commandType object.ApplicationCommandType
+}
+
+// SyncCommands makes the commands of the guild, or the global ones when guildID is empty, match the given
+// definitions. Only the commands which changed are created, edited or deleted.
+func (c *Client) SyncCommands(ctx context.Context, guildID string, commands []object.ApplicationCommand) error {
+	applicationID := c.cfg.ClientID
+	logger := log.Logger().WithField("guild_id", guildID)
+
+	remoteCommands, err := c.rest.GetApplicationCommands(ctx, applicationID, guildID)
+	if err != nil {
+		return fmt.Errorf("could not get application commands: %w", err)
+	}
+
+	remote := make(map[commandKey]object.ApplicationCommand, len(remoteCommands))
+	for _, command := range remoteCommands {
+		remote[keyOf(command)] = command
+	}
+
+	for i := range commands {
+		command := &commands[i]
+		key := keyOf(*command)
+
+		existing, ok := remote[key]
+		if !ok {
+			_, err = c.rest.CreateApplicationCommand(ctx, applicationID, guildID, command)
+			if err != nil {
+				return fmt.Errorf("could not create %s command: %w", command.Name, err)
+			}
+
+			logger.WithField("command", command.Name).Info("Created application command")
+			continue
+		}
+
+		delete(remote, key)
+
+		equal, err := commandsEqual(*command, existing, guildID == "")
+		if err != nil {
+			return err
+		}
+
+		if equal {
+			continue
+		}
+
+		_, err = c.rest.EditApplicationCommand(ctx, applicationID, guildID, existing.ID, command)
+		if err != nil {
+			return fmt.Errorf("could not edit %s command: %w", command.Name, err)
+		}
+
+		logger.WithField("command", command.Name).Info("Updated application command")
+	}
+
+	for _, command := range remote {
+		err = c.rest.DeleteApplicationCommand(ctx, applicationID, guildID, command.ID)
+		if err != nil {
+			return fmt.Errorf("could not delete %s command: %w", command.Name, err)
+		}
+
+		logger.WithField("command", command.Name).Info("Deleted application command")
+	}
+
+	return nil
+}
+
+func keyOf(command object.ApplicationCommand) commandKey {
+	commandType := command.Type
+	if commandType == 0 {
+		commandType = object.ChatInputCommand
+	}
+
+	return commandKey{name: command.Name, commandType: commandType}
+}
+
+// commandsEqual compares the declared command with the one returned by the API. Both are normalized
+// and compared as generic JSON so that e.g. integer choice values match the float64 ones Discord returns.
+func commandsEqual(local object.ApplicationCommand, remote object.ApplicationCommand, global bool) (bool, error) {
+	localValue, err := normalizeCommand(local, global)
+	if err != nil {
+		return false, err
+	}
+
+	remoteValue, err := normalizeCommand(remote, global)
+	if err != nil {
+		return false, err
+	}
+
+	return reflect.DeepEqual(localValue, remoteValue), nil
+}
+
+func normalizeCommand(command object.ApplicationCommand, global bool) (any, error) {
+	command.ID = ""
+	command.ApplicationID = ""
+	command.GuildID = nil
+	command.Version = ""
+	command.Type = keyOf(command).commandType
+
+	// Commands are usable in DMs unless stated otherwise, and guild commands do not have the setting at all.
+	if !global || (command.DMPermission != nil && *command.DMPermission) {
+		command.DMPermission = nil
+	}
+
+	data, err := json.Marshal(command)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal %s command: %w", command.Name, err)
+	}
+
+	var value any
+	err = json.Unmarshal(data, &value)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal %s command: %w", command.Name, err)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,236 @@
+package client
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bsponge/discordGopher/pkg/log"
+	"github.com/bsponge/discordGopher/pkg/object"
+	"github.com/bsponge/discordGopher/pkg/rest"
+)
+
+const (
+	playCommand       = "play"
+	skipCommand       = "skip"
+	pauseCommand      = "pause"
+	resumeCommand     = "resume"
+	stopCommand       = "stop"
+	queueCommand      = "queue"
+	nowPlayingCommand = "nowplaying"
+	removeCommand     = "remove"
+	clearCommand      = "clear"
+
+	queueEmbedTracks = 10
+	embedColor       = 0x00ADD8
+)
+
+// commandQueue runs the commands of a single guild one after another, in the order they were received.
+// Its goroutine only lives while there are commands to run.
+type commandQueue struct {
+	mtx     sync.Mutex
+	pending []func()
+	running bool
+}
+
+func (q *commandQueue) push(command func()) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	q.pending = append(q.pending, command)
+	if !q.running {
+		q.running = true
+		go q.run()
+	}
+}
+
+func (q *commandQueue) run() {
+	for {
+		q.mtx.Lock()
+		if len(q.pending) == 0 {
+			q.running = false
+			q.mtx.Unlock()
+			return
+		}
+
+		command := q.pending[0]
+		q.pending = q.pending[1:]
+		q.mtx.Unlock()
+
+		command()
+	}
+}
+
+func (c *Client) handleCommand(guildID string, message *object.Message, command string, args []string) {
+	logger := log.Logger().WithField("command", command).WithField("user", message.Author.Username)
+
+	response, err := c.runCommand(guildID, message.Author, command, args)
+	if err != nil {
+		logger.WithError(err).Error("Command failed")
+		response = textResponse(err.Error())
+	}
+
+	if response == nil {
+		return
+	}
+
+	err = c.reply(message, response)
+	if err != nil {
+		logger.WithError(err).Error("Could not reply to the command")
+	}
+}
+
+// runCommand executes the command invoked by the user in the guild and returns the message to reply with,
+// nil meaning no reply. It serves both the text commands and the slash commands.
+func (c *Client) runCommand(guildID string, user *object.User, command string, args []string) (*rest.MessageCreateParams, error) {
+	switch command {
+	case playCommand:
+		if len(args) == 0 {
+			return nil, fmt.Errorf("play command requires a file to play")
+		}
+
+		voiceState, ok := c.state.VoiceState(guildID, user.ID)
+		if !ok || voiceState.ChannelID == nil {
+			return nil, fmt.Errorf("you have to be in a voice channel to play music")
+		}
+
+		if c.library == nil {
+			return nil, fmt.Errorf("the music library is not configured")
+		}
+
+		name := filepath.Clean(strings.Join(args, " "))
+		path, err := c.library.Resolve(name)
+		if err != nil {
+			return nil, err
+		}
+
+		t := track{
+			path:        path,
+			name:        name,
+			requestedBy: user.Username,
+		}
+
+		position := c.getPlayer(guildID).Enqueue(t, *voiceState.ChannelID)
+		if position > 0 {
+			return textResponse(fmt.Sprintf("Queued %s at position %d", t.name, position)), nil
+		}
+
+		return textResponse(fmt.Sprintf("Playing %s", t.name)), nil
+	case skipCommand:
+		if !c.getPlayer(guildID).Skip() {
+			return textResponse("Nothing to skip"), nil
+		}
+
+		return textResponse("Skipped"), nil
+	case pauseCommand:
+		if !c.getPlayer(guildID).Pause() {
+			return textResponse("Nothing to pause"), nil
+		}
+
+		return textResponse("Paused"), nil
+	case resumeCommand:
+		if !c.getPlayer(guildID).Resume() {
+			return textResponse("Nothing to resume"), nil
+		}
+
+		return textResponse("Resumed"), nil
+	case stopCommand:
+		c.getPlayer(guildID).Stop()
+
+		return textResponse("Stopped"), nil
+	case queueCommand:
+		queue := c.getPlayer(guildID).Queue()
+		if len(queue) == 0 {
+			return textResponse("The queue is empty"), nil
+		}
+
+		return embedResponse(queueEmbed(queue))
+	case nowPlayingCommand:
+		p := c.getPlayer(guildID)
+		if _, ok := p.NowPlaying(); !ok {
+			return textResponse("Nothing is playing"), nil
+		}
+
+		return playerMessage(p)
+	case removeCommand:
+		if len(args) == 0 {
+			return nil, fmt.Errorf("remove command requires a queue position")
+		}
+
+		position, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid queue position %s", args[0])
+		}
+
+		t, err := c.getPlayer(guildID).Remove(position)
+		if err != nil {
+			return nil, err
+		}
+
+		return textResponse(fmt.Sprintf("Removed %s from the queue", t.name)), nil
+	case clearCommand:
+		cleared := c.getPlayer(guildID).Clear()
+
+		return textResponse(fmt.Sprintf("Removed %d tracks from the queue", cleared)), nil
+	default:
+		log.Logger().WithField("command", command).WithField("user", user.Username).Info("User used unknown command")
+	}
+
+	return nil, nil
+}
+
+func nowPlayingEmbed(t track) *object.EmbedBuilder {
+	return object.NewEmbedBuilder().
+		Title("Now playing").
+		Description(t.name).
+		Color(embedColor).
+		Footer(fmt.Sprintf("Requested by %s", t.requestedBy), "")
+}
+
+func queueEmbed(queue []track) *object.EmbedBuilder {
+	var sb strings.Builder
+	for i, t := range queue {
+		if i == queueEmbedTracks {
+			fmt.Fprintf(&sb, "... and %d more", len(queue)-queueEmbedTracks)
+			break
+		}
+
+		fmt.Fprintf(&sb, "%d. %s (requested by %s)\n", i+1, t.name, t.requestedBy)
+	}
+
+	return object.NewEmbedBuilder().
+		Title("Queue").
+		Description(sb.String()).
+		Color(embedColor).
+		Footer(fmt.Sprintf("%d tracks", len(queue)), "")
+}
+
+func textResponse(content string) *rest.MessageCreateParams {
+	return &rest.MessageCreateParams{
+		Content: content,
+	}
+}
+
+func embedResponse(builder *object.EmbedBuilder) (*rest.MessageCreateParams, error) {
+	embed, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return &rest.MessageCreateParams{
+		Embeds: []object.Embed{*embed},
+	}, nil
+}
+
+// reply answers the message without pinging anyone.
+func (c *Client) reply(message *object.Message, params *rest.MessageCreateParams) error {
+	params.AllowedMentions = &object.AllowedMentions{
+		Parse: []object.AllowedMentionType{},
+	}
+
+	_, err := c.rest.Reply(c.parentCtx, message, params)
+
+	return err
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bsponge/discordGopher/pkg/interaction"
+	"github.com/bsponge/discordGopher/pkg/object"
+	"github.com/bsponge/discordGopher/pkg/rest"
+)
+
+func TestCommandQueueKeepsOrder(t *testing.T) {
+	var q commandQueue
+	var wg sync.WaitGroup
+
+	var mtx sync.Mutex
+	var order []int
+	for i := 0; i < 50; i++ {
+		i := i
+		wg.Add(1)
+		q.push(func() {
+			defer wg.Done()
+
+			// The first command is slow, the later ones must still wait for it.
+			if i == 0 {
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			mtx.Lock()
+			order = append(order, i)
+			mtx.Unlock()
+		})
+	}
+
+	wg.Wait()
+
+	for i, command := range order {
+		if command != i {
+			t.Fatalf("commands ran in order %v", order)
+		}
+	}
+}
+
+func TestSlashCommandWaitsForGuildQueue(t *testing.T) {
+	edits := make(chan string, 1)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params struct {
+			Content string `json:"content"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&params)
+		edits <- r.Method + " " + params.Content
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"1149435310120411136","channel_id":"1082683372128055326"}`))
+	}))
+	defer api.Close()
+
+	restClient := rest.NewClient("token", rest.WithBaseURL(api.URL))
+	c := &Client{
+		parentCtx:    context.Background(),
+		rest:         restClient,
+		interactions: interaction.NewRouter(restClient),
+		players:      make(map[string]*player),
+		commands:     make(map[string]*commandQueue),
+	}
+	c.registerPlayerCommands()
+
+	// A text command of the guild is still running.
+	guildID := "1082683371452780544"
+	release := make(chan struct{})
+	c.getCommandQueue(guildID).push(func() {
+		<-release
+	})
+
+	i := &object.Interaction{
+		ID:            "1149435305884594278",
+		ApplicationID: "1082680961921613945",
+		Type:          object.ApplicationCommandInteraction,
+		Data:          &object.InteractionData{Name: skipCommand},
+		GuildID:       &guildID,
+		Member:        &object.GuildMember{User: &object.User{ID: "284102390608347136", Username: "bsponge"}},
+		Token:         "aW50ZXJhY3Rpb246MTE0OTQzNTMwNTg4NDU5NDI3OA",
+	}
+
+	var responses []object.InteractionCallbackType
+	err := c.interactions.Handle(context.Background(), i, func(ctx context.Context, response *object.InteractionResponse) error {
+		responses = append(responses, response.Type)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 1 || responses[0] != object.DeferredChannelMessageWithSourceCallback {
+		t.Fatalf("got responses %v, want a deferred response", responses)
+	}
+
+	select {
+	case edit := <-edits:
+		t.Fatalf("the command ran before the queued one finished: %s", edit)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+
+	select {
+	case edit := <-edits:
+		if edit != http.MethodPatch+" Nothing to skip" {
+			t.Fatalf("got %q, want the reply of the skip command", edit)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the deferred response was not edited")
+	}
+}
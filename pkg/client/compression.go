@@ -0,0 +1,196 @@
+package client
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	compressKey = "compress"
+
+	zlibStream = "zlib-stream"
+	zstdStream = "zstd-stream"
+
+	inflateBufferSize = 32 * 1024
+)
+
+// zlibSuffix ends every zlib-stream message, a message can be split into several websocket frames.
+var zlibSuffix = []byte{0x00, 0x00, 0xff, 0xff}
+
+var errNoMessage = errors.New("the compressed frame did not contain a message")
+
+func checkCompression(compression string) error {
+	switch compression {
+	case "", zlibStream, zstdStream:
+		return nil
+	default:
+		return fmt.Errorf("unsupported gateway compression %q", compression)
+	}
+}
+
+type inflateResult struct {
+	payload []byte
+	err     error
+}
+
+// inflater decompresses the messages of a single gateway connection. Discord compresses the whole connection as
+// one stream and flushes it after every message, so the frames are written into a shared decompression context.
+// The decompressors read their input byte by byte and only ask for the next frame once they have returned all the
+// output of the previous ones, which is then the complete message.
+type inflater struct {
+	compression string
+	results     chan inflateResult
+	// buffer collects the frames of a zlib-stream message until its suffix arrives.
+	buffer []byte
+
+	// The fields below are only used by the goroutine running the decompressor.
+	frames  chan []byte
+	closed  chan struct{}
+	frame   []byte
+	pending bool
+	output  []byte
+}
+
+func newInflater(compression string) *inflater {
+	i := &inflater{
+		compression: compression,
+		results:     make(chan inflateResult),
+		frames:      make(chan []byte),
+		closed:      make(chan struct{}),
+	}
+
+	go i.run()
+
+	return i
+}
+
+// inflate returns the payload completed by the frame, nil if the message continues in the next frames.
+func (i *inflater) inflate(ctx context.Context, frame []byte) ([]byte, error) {
+	if i.compression == zlibStream {
+		i.buffer = append(i.buffer, frame...)
+		if !bytes.HasSuffix(i.buffer, zlibSuffix) {
+			return nil, nil
+		}
+		frame, i.buffer = i.buffer, nil
+	}
+
+	select {
+	case i.frames <- frame:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case result := <-i.results:
+		return result.payload, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (i *inflater) Close() {
+	close(i.closed)
+}
+
+func (i *inflater) run() {
+	stream, err := i.decompressor()
+	if err != nil {
+		i.send(inflateResult{err: fmt.Errorf("could not start %s decompression: %w", i.compression, err)})
+		return
+	}
+	defer stream.Close()
+
+	buf := make([]byte, inflateBufferSize)
+	for {
+		n, err := stream.Read(buf)
+		i.output = append(i.output, buf[:n]...)
+		if err != nil {
+			i.send(inflateResult{err: fmt.Errorf("could not decompress payload: %w", err)})
+			return
+		}
+	}
+}
+
+func (i *inflater) decompressor() (io.ReadCloser, error) {
+	if i.compression == zstdStream {
+		// A single goroutine decodes the stream block by block, without reading ahead.
+		decoder, err := zstd.NewReader(i, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	}
+
+	// Reading the zlib header blocks until the first frame arrives.
+	return zlib.NewReader(i)
+}
+
+func (i *inflater) send(result inflateResult) bool {
+	select {
+	case i.results <- result:
+		return true
+	case <-i.closed:
+		return false
+	}
+}
+
+// next waits for the next frame once the current one has been read. The output decompressed from the previous frame
+// is sent as its message first.
+func (i *inflater) next() error {
+	for len(i.frame) == 0 {
+		if i.pending {
+			i.pending = false
+
+			if len(i.output) == 0 {
+				return errNoMessage
+			}
+
+			payload := i.output
+			i.output = nil
+			if !i.send(inflateResult{payload: payload}) {
+				return io.EOF
+			}
+		}
+
+		select {
+		case i.frame = <-i.frames:
+			i.pending = true
+		case <-i.closed:
+			return io.EOF
+		}
+	}
+
+	return nil
+}
+
+// Read provides the compressed stream to the decompressor.
+func (i *inflater) Read(p []byte) (int, error) {
+	err := i.next()
+	if err != nil {
+		return 0, err
+	}
+
+	n := copy(p, i.frame)
+	i.frame = i.frame[n:]
+
+	return n, nil
+}
+
+// ReadByte keeps the decompressors from buffering the input, they would read into the next frame otherwise.
+func (i *inflater) ReadByte() (byte, error) {
+	err := i.next()
+	if err != nil {
+		return 0, err
+	}
+
+	b := i.frame[0]
+	i.frame = i.frame[1:]
+
+	return b, nil
+}
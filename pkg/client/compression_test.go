@@ -0,0 +1,131 @@
+package client
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var testGatewayMessages = []string{
+	`{"op":10,"d":{"heartbeat_interval":41250}}`,
+	`{"op":11}`,
+	`{"t":"MESSAGE_CREATE","s":2,"op":0,"d":{"id":"1149071652235489301","content":"play song.ogg"}}`,
+}
+
+func testInflateContext(t *testing.T) context.Context {
+	t.Helper()
+
+	// A broken inflater blocks, the deadline turns that into a failure.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	return ctx
+}
+
+func TestInflaterZlibStream(t *testing.T) {
+	ctx := testInflateContext(t)
+
+	i := newInflater(zlibStream)
+	defer i.Close()
+
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+
+	for n, message := range testGatewayMessages {
+		compressed.Reset()
+		_, err := w.Write([]byte(message))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = w.Flush()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		frames := [][]byte{compressed.Bytes()}
+		// The last message is split into two websocket frames.
+		if n == len(testGatewayMessages)-1 {
+			frames = [][]byte{compressed.Bytes()[:5], compressed.Bytes()[5:]}
+		}
+
+		var payload []byte
+		for _, frame := range frames {
+			payload, err = i.inflate(ctx, append([]byte(nil), frame...))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if string(payload) != message {
+			t.Fatalf("got payload %q, want %q", payload, message)
+		}
+	}
+}
+
+func TestInflaterZstdStream(t *testing.T) {
+	ctx := testInflateContext(t)
+
+	i := newInflater(zstdStream)
+	defer i.Close()
+
+	var compressed bytes.Buffer
+	w, err := zstd.NewWriter(&compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, message := range testGatewayMessages {
+		compressed.Reset()
+		_, err := w.Write([]byte(message))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = w.Flush()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		payload, err := i.inflate(ctx, append([]byte(nil), compressed.Bytes()...))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(payload) != message {
+			t.Fatalf("got payload %q, want %q", payload, message)
+		}
+	}
+}
+
+func TestInflaterFrameWithoutMessage(t *testing.T) {
+	ctx := testInflateContext(t)
+
+	i := newInflater(zlibStream)
+	defer i.Close()
+
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	_, err := w.Write([]byte(testGatewayMessages[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = i.inflate(ctx, compressed.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An empty flush decompresses to nothing, it must fail instead of waiting for a message forever.
+	_, err = i.inflate(ctx, append([]byte{0x00}, zlibSuffix...))
+	if !errors.Is(err, errNoMessage) {
+		t.Fatalf("got error %v, want %v", err, errNoMessage)
+	}
+}
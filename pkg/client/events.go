@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"sync"
+
+	"github.com/bsponge/discordGopher/pkg/log"
+	"github.com/bsponge/discordGopher/pkg/object"
+)
+
+var (
+	contextType    = reflect.TypeOf((*context.Context)(nil)).Elem()
+	rawPayloadType = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// dispatchPayloadTypes maps the dispatches to the types their payloads are unmarshaled into. Handlers of the
+// dispatches which are not listed here may take any type.
+var dispatchPayloadTypes = map[object.Dispatch]reflect.Type{
+	object.ReadyType:                 reflect.TypeOf(object.Ready{}),
+	object.ResumedType:               reflect.TypeOf(object.Resumed{}),
+	object.GuildCreateType:           reflect.TypeOf(object.Guild{}),
+	object.GuildUpdateType:           reflect.TypeOf(object.Guild{}),
+	object.GuildDeleteType:           reflect.TypeOf(object.UnavailableGuild{}),
+	object.GuildMemberAddType:        reflect.TypeOf(object.GuildMemberAdd{}),
+	object.GuildMemberUpdateType:     reflect.TypeOf(object.GuildMemberUpdate{}),
+	object.GuildMemberRemoveType:     reflect.TypeOf(object.GuildMemberRemove{}),
+	object.GuildRoleCreateType:       reflect.TypeOf(object.GuildRole{}),
+	object.GuildRoleUpdateType:       reflect.TypeOf(object.GuildRole{}),
+	object.GuildRoleDeleteType:       reflect.TypeOf(object.GuildRoleDelete{}),
+	object.ChannelCreateType:         reflect.TypeOf(object.Channel{}),
+	object.ChannelUpdateType:         reflect.TypeOf(object.Channel{}),
+	object.ChannelDeleteType:         reflect.TypeOf(object.Channel{}),
+	object.ThreadCreateType:          reflect.TypeOf(object.Channel{}),
+	object.ThreadUpdateType:          reflect.TypeOf(object.Channel{}),
+	object.ThreadDeleteType:          reflect.TypeOf(object.Channel{}),
+	object.ThreadListSyncType:        reflect.TypeOf(object.ThreadListSync{}),
+	object.ThreadMemberUpdateType:    reflect.TypeOf(object.ThreadMemberUpdate{}),
+	object.ThreadMembersUpdateType:   reflect.TypeOf(object.ThreadMembersUpdate{}),
+	object.MessageCreateType:         reflect.TypeOf(object.Message{}),
+	object.MessageUpdateType:         reflect.TypeOf(object.Message{}),
+	object.MessageDeleteType:         reflect.TypeOf(object.MessageDelete{}),
+	object.MessageDeleteBulkType:     reflect.TypeOf(object.MessageDeleteBulk{}),
+	object.MessageReactionAddType:    reflect.TypeOf(object.MessageReactionAdd{}),
+	object.MessageReactionRemoveType: reflect.TypeOf(object.MessageReactionRemove{}),
+	object.PresenceUpdateType:        reflect.TypeOf(object.PresenceUpdate{}),
+	object.TypingStartType:           reflect.TypeOf(object.TypingStart{}),
+	object.VoiceStateUpdateType:      reflect.TypeOf(object.VoiceState{}),
+	object.VoiceServerUpdateType:     reflect.TypeOf(object.VoiceServerUpdate{}),
+	object.InteractionCreateType:     reflect.TypeOf(object.Interaction{}),
+}
+
+type eventHandler struct {
+	id          uint64
+	fn          reflect.Value
+	payloadType reflect.Type
+}
+
+// eventRegistry keeps the handlers subscribed to gateway dispatches.
+type eventRegistry struct {
+	mtx       sync.RWMutex
+	unmarshal func(data []byte, v any) error
+
+	nextID   uint64
+	handlers map[object.Dispatch][]*eventHandler
+}
+
+func newEventRegistry(unmarshal func(data []byte, v any) error) *eventRegistry {
+	return &eventRegistry{
+		unmarshal: unmarshal,
+		handlers:  make(map[object.Dispatch][]*eventHandler),
+	}
+}
+
+// On subscribes the handler to the dispatch. The handler has to be a func(context.Context, *T)
+// where T is the type the dispatch payload is unmarshaled into, e.g. func(context.Context, *object.Message)
+// for object.MessageCreateType or func(context.Context, *json.RawMessage) for the raw payload, which is JSON
+// with both gateway encodings. A handler taking another type than the payload of the dispatch is refused.
+//
+// Handlers are called one after another from the gateway read loop, so they should not block.
+// A panicking handler is recovered and does not affect the other handlers. The returned function
+// removes the subscription.
+func (c *Client) On(dispatch object.Dispatch, handler any) (func(), error) {
+	return c.events.subscribe(dispatch, handler)
+}
+
+func (r *eventRegistry) subscribe(dispatch object.Dispatch, handler any) (func(), error) {
+	fn := reflect.ValueOf(handler)
+	if !fn.IsValid() || (fn.Kind() == reflect.Func && fn.IsNil()) {
+		return nil, fmt.Errorf("handler must not be nil")
+	}
+	if fn.Kind() != reflect.Func {
+		return nil, fmt.Errorf("handler has to be a func(context.Context, *T), got %T", handler)
+	}
+
+	fnType := fn.Type()
+	if fnType.NumIn() != 2 || fnType.NumOut() != 0 ||
+		fnType.In(0) != contextType || fnType.In(1).Kind() != reflect.Pointer {
+		return nil, fmt.Errorf("handler has to be a func(context.Context, *T), got %s", fnType)
+	}
+
+	payloadType := fnType.In(1).Elem()
+	if expected, ok := dispatchPayloadTypes[dispatch]; ok && payloadType != expected && payloadType != rawPayloadType {
+		return nil, fmt.Errorf("%s handler has to take *%s or *json.RawMessage, got *%s", dispatch, expected, payloadType)
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.nextID++
+	id := r.nextID
+
+	r.handlers[dispatch] = append(r.handlers[dispatch], &eventHandler{
+		id:          id,
+		fn:          fn,
+		payloadType: payloadType,
+	})
+
+	return func() {
+		r.unsubscribe(dispatch, id)
+	}, nil
+}
+
+func (r *eventRegistry) unsubscribe(dispatch object.Dispatch, id uint64) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	handlers := r.handlers[dispatch]
+	for i, handler := range handlers {
+		if handler.id != id {
+			continue
+		}
+
+		// The slice is copied so that a dispatch in progress keeps iterating over the old one.
+		remaining := make([]*eventHandler, 0, len(handlers)-1)
+		remaining = append(remaining, handlers[:i]...)
+		remaining = append(remaining, handlers[i+1:]...)
+		r.handlers[dispatch] = remaining
+
+		return
+	}
+}
+
+// dispatch calls all handlers subscribed to the dispatch and returns how many there were.
+func (r *eventRegistry) dispatch(ctx context.Context, dispatch object.Dispatch, payload []byte) int {
+	r.mtx.RLock()
+	handlers := r.handlers[dispatch]
+	r.mtx.RUnlock()
+
+	for _, handler := range handlers {
+		handler.call(ctx, dispatch, payload, r.unmarshal)
+	}
+
+	return len(handlers)
+}
+
+func (h *eventHandler) call(ctx context.Context, dispatch object.Dispatch, payload []byte, unmarshal func([]byte, any) error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Logger().WithField("dispatch_type", dispatch).WithField("stack", string(debug.Stack())).
+				Errorf("Event handler panicked: %v", r)
+		}
+	}()
+
+	value := reflect.New(h.payloadType)
+	err := unmarshal(payload, value.Interface())
+	if err != nil {
+		log.Logger().WithError(err).WithField("dispatch_type", dispatch).
+			Errorf("Could not unmarshal dispatch payload into %s", h.payloadType)
+		return
+	}
+
+	h.fn.Call([]reflect.Value{reflect.ValueOf(ctx), value})
+}
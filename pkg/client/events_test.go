@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/bsponge/discordGopher/pkg/object"
+)
+
+func TestEventRegistrySubscribe(t *testing.T) {
+	r := newEventRegistry(json.Unmarshal)
+
+	var content string
+	_, err := r.subscribe(object.MessageCreateType, func(ctx context.Context, message *object.Message) {
+		content = *message.Content
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.subscribe(object.MessageCreateType, func(ctx context.Context, payload *json.RawMessage) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.subscribe(object.MessageCreateType, func(ctx context.Context, guild *object.Guild) {})
+	if err == nil {
+		t.Fatal("expected a handler with the wrong payload type to be refused")
+	}
+
+	_, err = r.subscribe(object.MessageCreateType, func(message object.Message) {})
+	if err == nil {
+		t.Fatal("expected a handler with the wrong signature to be refused")
+	}
+
+	handlers := r.dispatch(context.Background(), object.MessageCreateType, []byte(`{"id":"1","content":"hello"}`))
+	if handlers != 2 {
+		t.Fatalf("got %d handlers, want 2", handlers)
+	}
+	if content != "hello" {
+		t.Fatalf("got content %q, want %q", content, "hello")
+	}
+}
+
+func TestEventRegistryRefusesInvalidHandlers(t *testing.T) {
+	r := newEventRegistry(json.Unmarshal)
+
+	var nilHandler func(context.Context, *object.Message)
+	for name, handler := range map[string]any{
+		"nil":          nil,
+		"nil func":     nilHandler,
+		"not a func":   "handler",
+		"func pointer": &nilHandler,
+	} {
+		_, err := r.subscribe(object.MessageCreateType, handler)
+		if err == nil {
+			t.Fatalf("expected the %s handler to be refused", name)
+		}
+	}
+}
+
+func TestEventRegistryUnsubscribe(t *testing.T) {
+	r := newEventRegistry(json.Unmarshal)
+
+	var calls int
+	unsubscribe, err := r.subscribe(object.MessageCreateType, func(ctx context.Context, message *object.Message) {
+		calls++
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.dispatch(context.Background(), object.MessageCreateType, []byte(`{"id":"1"}`))
+	unsubscribe()
+	handlers := r.dispatch(context.Background(), object.MessageCreateType, []byte(`{"id":"2"}`))
+
+	if calls != 1 || handlers != 0 {
+		t.Fatalf("got %d calls and %d handlers after unsubscribing, want 1 and 0", calls, handlers)
+	}
+
+	// Unsubscribing twice is harmless.
+	unsubscribe()
+}
+
+func TestEventRegistryRecoversPanics(t *testing.T) {
+	r := newEventRegistry(json.Unmarshal)
+
+	_, err := r.subscribe(object.MessageCreateType, func(ctx context.Context, message *object.Message) {
+		panic("handler bug")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var id string
+	_, err = r.subscribe(object.MessageCreateType, func(ctx context.Context, message *object.Message) {
+		id = message.ID
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handlers := r.dispatch(context.Background(), object.MessageCreateType, []byte(`{"id":"1149071652235489301"}`))
+	if handlers != 2 || id != "1149071652235489301" {
+		t.Fatalf("got %d handlers and id %q, want the handler after the panicking one to run", handlers, id)
+	}
+}
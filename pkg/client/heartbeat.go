@@ -2,19 +2,21 @@ package client
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/bsponge/discordGopher/pkg/log"
 
 	"nhooyr.io/websocket"
-	"nhooyr.io/websocket/wsjson"
 )
 
 type heartbeatService struct {
-	ctx    context.Context
-	closed chan struct{}
+	shard *shard
 
-	client *Client
+	mtx      sync.Mutex
+	ctx      context.Context
+	closed   chan struct{}
+	lastSent time.Time
 }
 
 type heartbeat struct {
@@ -26,53 +28,65 @@ type SequenceGetter interface {
 	GetSequence() int
 }
 
-func NewHeartbeatService(client *Client) *heartbeatService {
+func NewHeartbeatService(shard *shard) *heartbeatService {
 	return &heartbeatService{
-		client: client,
+		shard: shard,
 	}
 }
 
 func (s *heartbeatService) SendHeartbeat(gatewayWebsocket *websocket.Conn) error {
 	hb := &heartbeat{
 		Op: 1,
-		D:  s.client.GetSequence(),
+		D:  s.shard.GetSequence(),
 	}
 
 	log.Logger().Trace("Sending heartbeat")
 
-	err := wsjson.Write(s.ctx, gatewayWebsocket, hb)
+	s.mtx.Lock()
+	ctx := s.ctx
+	s.mtx.Unlock()
+
+	err := s.shard.write(ctx, gatewayWebsocket, hb)
 	if err != nil {
 		return err
 	}
 
+	s.mtx.Lock()
+	s.lastSent = time.Now()
+	s.mtx.Unlock()
+
 	return nil
 }
 
-func (s *heartbeatService) Start(ctx context.Context, gatewayWebsocket *websocket.Conn, interval int, resuming bool) error {
-	s.ctx = ctx
-	s.closed = make(chan struct{})
+// SinceLastHeartbeat returns the time elapsed since the last heartbeat was sent.
+func (s *heartbeatService) SinceLastHeartbeat() time.Duration {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
 
-	timer := time.NewTicker(time.Duration(interval) * time.Millisecond)
+	return time.Since(s.lastSent)
+}
 
-	err := s.SendHeartbeat(gatewayWebsocket)
-	if err != nil {
-		return err
-	}
+// Start sends the first heartbeat followed by the identify or the resume and keeps sending heartbeats until ctx is
+// done. The loop runs even when the first writes fail, so that Stop returns once ctx is cancelled.
+func (s *heartbeatService) Start(ctx context.Context, gatewayWebsocket *websocket.Conn, interval int, resuming bool) error {
+	closed := make(chan struct{})
 
-	if !resuming {
-		err = s.client.Identify()
-		if err != nil {
-			return err
-		}
-	}
+	s.mtx.Lock()
+	s.ctx = ctx
+	s.closed = closed
+	s.mtx.Unlock()
+
+	ticker := time.NewTicker(time.Duration(interval) * time.Millisecond)
 
 	go func() {
+		defer close(closed)
+		defer ticker.Stop()
+
 		for {
 			select {
-			case <-s.ctx.Done():
-				close(s.closed)
+			case <-ctx.Done():
 				return
-			case <-timer.C:
+			case <-ticker.C:
 			}
 
 			err := s.SendHeartbeat(gatewayWebsocket)
@@ -82,9 +96,27 @@ func (s *heartbeatService) Start(ctx context.Context, gatewayWebsocket *websocke
 		}
 	}()
 
-	return nil
+	err := s.SendHeartbeat(gatewayWebsocket)
+	if err != nil {
+		return err
+	}
+
+	if resuming {
+		return s.shard.Resume(ctx, gatewayWebsocket)
+	}
+
+	return s.shard.Identify(ctx, gatewayWebsocket)
 }
 
 func (s *heartbeatService) Stop() {
-	<-s.closed
+	s.mtx.Lock()
+	closed := s.closed
+	s.mtx.Unlock()
+
+	// The service is not running until the first Hello.
+	if closed == nil {
+		return
+	}
+
+	<-closed
 }
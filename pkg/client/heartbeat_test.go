@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nhooyr.io/websocket"
+)
+
+func TestHeartbeatStopAfterFailedStart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		ws.Close(websocket.StatusNormalClosure, "")
+	}))
+	defer server.Close()
+
+	ws, _, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.Close(websocket.StatusNormalClosure, "")
+
+	s := &shard{id: 1, client: &Client{codec: jsonCodec{}}}
+	hb := NewHeartbeatService(s)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	err = hb.Start(ctx, ws, 60000, false)
+	if err == nil {
+		t.Fatal("expected the first heartbeat to fail on a closed connection")
+	}
+
+	cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		hb.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop blocked after a failed Start")
+	}
+}
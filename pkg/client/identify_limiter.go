@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bsponge/discordGopher/pkg/log"
+	"github.com/bsponge/discordGopher/pkg/object"
+)
+
+const (
+	// identifyInterval is the time Discord requires between two identifies in the same rate limit bucket.
+	identifyInterval = 5 * time.Second
+	// sessionStartLimitPeriod is used when Discord did not tell when the session start limit resets.
+	sessionStartLimitPeriod = 24 * time.Hour
+)
+
+// identifyLimiter spaces out identifies so that they respect max_concurrency and the daily session start limit.
+// Shard i identifies in bucket i % max_concurrency and each bucket allows one identify per identifyInterval.
+// Once the session starts are used up, identifies wait for the limit to reset instead of being rejected.
+type identifyLimiter struct {
+	mtx sync.Mutex
+
+	maxConcurrency int
+	total          int
+	remaining      int
+	resetAt        time.Time
+
+	nextIdentify map[int]time.Time
+	// reserved keeps the slot each shard took last, so that it can be given back.
+	reserved map[int]time.Time
+}
+
+func newIdentifyLimiter() *identifyLimiter {
+	return &identifyLimiter{
+		maxConcurrency: 1,
+		remaining:      1,
+		nextIdentify:   make(map[int]time.Time),
+		reserved:       make(map[int]time.Time),
+	}
+}
+
+// update replaces the limits with the ones reported by /gateway/bot.
+func (l *identifyLimiter) update(limit object.SessionStartLimit) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.maxConcurrency = limit.MaxConcurrency
+	if l.maxConcurrency < 1 {
+		l.maxConcurrency = 1
+	}
+
+	l.total = limit.Total
+	l.remaining = limit.Remaining
+	l.resetAt = time.Now().Add(time.Duration(limit.ResetAfter) * time.Millisecond)
+}
+
+// wait blocks until the shard is allowed to identify and takes one session start from the budget.
+func (l *identifyLimiter) wait(ctx context.Context, shardID int) error {
+	warned := false
+
+	for {
+		delay, ok := l.reserve(shardID)
+		if ok {
+			return nil
+		}
+
+		if delay > identifyInterval && !warned {
+			warned = true
+			log.Logger().WithField("shard", shardID).WithField("wait", delay.String()).
+				Warn("The session start limit has been used up, waiting for it to reset")
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// reserve takes an identify slot if one is available, otherwise it returns how long to wait for one.
+func (l *identifyLimiter) reserve(shardID int) (time.Duration, bool) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	now := time.Now()
+
+	if l.remaining <= 0 {
+		if now.Before(l.resetAt) {
+			return l.resetAt.Sub(now), false
+		}
+
+		l.remaining = l.total
+		l.resetAt = now.Add(sessionStartLimitPeriod)
+	}
+
+	bucket := shardID % l.maxConcurrency
+	if next := l.nextIdentify[bucket]; now.Before(next) {
+		return next.Sub(now), false
+	}
+
+	l.nextIdentify[bucket] = now.Add(identifyInterval)
+	l.reserved[shardID] = l.nextIdentify[bucket]
+	l.remaining--
+
+	return 0, true
+}
+
+// release gives back the slot and the session start taken by the shard when it did not identify after all,
+// e.g. because the connection could not be established.
+func (l *identifyLimiter) release(shardID int) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	next, ok := l.reserved[shardID]
+	if !ok {
+		return
+	}
+	delete(l.reserved, shardID)
+
+	// Another shard of the bucket may have taken the following slot in the meantime, its spacing is kept.
+	bucket := shardID % l.maxConcurrency
+	if l.nextIdentify[bucket] == next {
+		delete(l.nextIdentify, bucket)
+	}
+
+	if l.remaining < l.total {
+		l.remaining++
+	}
+}
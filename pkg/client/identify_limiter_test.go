@@ -0,0 +1,48 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bsponge/discordGopher/pkg/object"
+)
+
+func TestIdentifyLimiterRelease(t *testing.T) {
+	l := newIdentifyLimiter()
+	l.update(object.SessionStartLimit{Total: 1000, Remaining: 1, ResetAfter: int(time.Hour / time.Millisecond), MaxConcurrency: 1})
+
+	if _, ok := l.reserve(0); !ok {
+		t.Fatal("expected the first identify to be allowed")
+	}
+	if _, ok := l.reserve(0); ok {
+		t.Fatal("expected the session starts to be used up")
+	}
+
+	// The connection could not be established, so the slot is given back and the retry does not wait.
+	l.release(0)
+
+	if _, ok := l.reserve(0); !ok {
+		t.Fatal("expected the released slot to be taken again")
+	}
+}
+
+func TestIdentifyLimiterReleaseKeepsLaterSlots(t *testing.T) {
+	l := newIdentifyLimiter()
+	l.update(object.SessionStartLimit{Total: 1000, Remaining: 1000, MaxConcurrency: 1})
+
+	if _, ok := l.reserve(0); !ok {
+		t.Fatal("expected the first identify to be allowed")
+	}
+
+	// Shard 1 takes the next slot of the bucket before shard 0 gives its slot back.
+	l.nextIdentify[0] = time.Now()
+	if _, ok := l.reserve(1); !ok {
+		t.Fatal("expected the second identify to be allowed")
+	}
+
+	l.release(0)
+
+	if delay, ok := l.reserve(2); ok || delay <= 0 {
+		t.Fatal("expected the spacing after the identify of shard 1 to be kept")
+	}
+}
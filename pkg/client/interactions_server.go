@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/bsponge/discordGopher/pkg/interaction"
+	"github.com/bsponge/discordGopher/pkg/log"
+)
+
+const (
+	interactionsPath = "/interactions"
+
+	readHeaderTimeout = 5 * time.Second
+	shutdownTimeout   = 5 * time.Second
+)
+
+// startInteractionsServer serves the interactions endpoint on the configured address until ctx is canceled.
+// The endpoint uses the same router as the gateway, so commands work no matter where Discord sends them.
+func (c *Client) startInteractionsServer(ctx context.Context) error {
+	key := c.cfg.PublicKey
+	if key == "" {
+		application, err := c.rest.GetCurrentApplication(ctx)
+		if err != nil {
+			return fmt.Errorf("could not get the public key of the application: %w", err)
+		}
+
+		key = application.VerifyKey
+	}
+
+	publicKey, err := interaction.ParsePublicKey(key)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(interactionsPath, interaction.NewHTTPHandler(ctx, c.interactions, publicKey))
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	listener, err := net.Listen("tcp", c.cfg.InteractionsAddress)
+	if err != nil {
+		return fmt.Errorf("could not listen on %s: %w", c.cfg.InteractionsAddress, err)
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		err := server.Shutdown(shutdownCtx)
+		if err != nil {
+			log.Logger().WithError(err).Error("Could not shut down the interactions endpoint")
+		}
+	}()
+
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Logger().WithError(err).Error("The interactions endpoint failed")
+		}
+	}()
+
+	log.Logger().Infof("Serving interactions on %s%s", listener.Addr(), interactionsPath)
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// libraryRescanInterval bounds how stale the list of tracks can get, scanning on every keystroke would be too slow.
+const libraryRescanInterval = time.Minute
+
+var trackExtensions = map[string]bool{
+	".ogg":  true,
+	".opus": true,
+}
+
+// trackLibrary lists the tracks in the music directory, their paths are relative to it.
+type trackLibrary struct {
+	dir string
+
+	mtx     sync.Mutex
+	tracks  []string
+	scanned time.Time
+}
+
+func newTrackLibrary(dir string) *trackLibrary {
+	return &trackLibrary{
+		dir: dir,
+	}
+}
+
+// Search returns up to limit tracks whose paths contain the query, ignoring case.
+func (l *trackLibrary) Search(query string, limit int) ([]string, error) {
+	tracks, err := l.list()
+	if err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(query)
+
+	var found []string
+	for _, track := range tracks {
+		if len(found) == limit {
+			break
+		}
+
+		if strings.Contains(strings.ToLower(track), query) {
+			found = append(found, track)
+		}
+	}
+
+	return found, nil
+}
+
+// Resolve returns the path of the track in the library. Absolute paths, paths leaving the library, also through
+// symlinks, and anything that is not a regular file are refused.
+func (l *trackLibrary) Resolve(path string) (string, error) {
+	path = filepath.Clean(path)
+	if filepath.IsAbs(path) || containsParent(path) {
+		return "", fmt.Errorf("track %q is not in the music library", path)
+	}
+
+	dir, err := filepath.EvalSymlinks(l.dir)
+	if err != nil {
+		return "", fmt.Errorf("could not open the music library: %w", err)
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("could not open the music library: %w", err)
+	}
+
+	resolved, err := filepath.EvalSymlinks(filepath.Join(dir, path))
+	if err != nil {
+		return "", fmt.Errorf("track %q is not in the music library", path)
+	}
+	resolved, err = filepath.Abs(resolved)
+	if err != nil || !strings.HasPrefix(resolved, dir+string(filepath.Separator)) {
+		return "", fmt.Errorf("track %q is not in the music library", path)
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", fmt.Errorf("track %q is not in the music library", path)
+	}
+
+	return resolved, nil
+}
+
+func containsParent(path string) bool {
+	for _, element := range strings.Split(filepath.ToSlash(path), "/") {
+		if element == ".." {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l *trackLibrary) list() ([]string, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if l.tracks != nil && time.Since(l.scanned) < libraryRescanInterval {
+		return l.tracks, nil
+	}
+
+	tracks := []string{}
+	err := filepath.WalkDir(l.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || !trackExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+
+		relative, err := filepath.Rel(l.dir, path)
+		if err != nil {
+			return err
+		}
+
+		// Symlinks pointing outside of the library would be refused when played, so they are not suggested.
+		if entry.Type()&fs.ModeSymlink != 0 {
+			if _, err := l.Resolve(relative); err != nil {
+				return nil
+			}
+		}
+
+		tracks = append(tracks, relative)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l.tracks = tracks
+	l.scanned = time.Now()
+
+	return tracks, nil
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTrackLibraryResolve(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "music")
+	writeTestFile(t, filepath.Join(dir, "album", "song.ogg"))
+	writeTestFile(t, filepath.Join(root, "secret.ogg"))
+
+	err := os.Symlink(filepath.Join(root, "secret.ogg"), filepath.Join(dir, "escape.ogg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Symlink(filepath.Join(dir, "album", "song.ogg"), filepath.Join(dir, "link.ogg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	library := newTrackLibrary(dir)
+
+	for _, path := range []string{"album/song.ogg", "./album/../album/song.ogg", "link.ogg"} {
+		resolved, err := library.Resolve(path)
+		if err != nil {
+			t.Fatalf("could not resolve %q: %v", path, err)
+		}
+
+		want, _ := filepath.EvalSymlinks(filepath.Join(dir, "album", "song.ogg"))
+		if resolved != want {
+			t.Fatalf("resolved %q to %q, want %q", path, resolved, want)
+		}
+	}
+
+	refused := []string{
+		filepath.Join(root, "secret.ogg"),
+		"../secret.ogg",
+		"album/../../secret.ogg",
+		"escape.ogg",
+		"album",
+		".",
+		"missing.ogg",
+	}
+	for _, path := range refused {
+		_, err := library.Resolve(path)
+		if err == nil {
+			t.Fatalf("expected %q to be refused", path)
+		}
+	}
+
+	tracks, err := library.Search("", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, track := range tracks {
+		if track == "escape.ogg" {
+			t.Fatalf("suggested the track outside of the library: %v", tracks)
+		}
+	}
+}
+
+func writeTestFile(t *testing.T, path string) {
+	t.Helper()
+
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(path, nil, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,370 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/bsponge/discordGopher/pkg/audio"
+	"github.com/bsponge/discordGopher/pkg/log"
+)
+
+type track struct {
+	// id identifies the track in the queue of its player, unlike its position it does not change as the queue moves.
+	id uint64
+	// path is the resolved path of the file, name is the path relative to the library shown to users.
+	path        string
+	name        string
+	requestedBy string
+}
+
+// player owns the voice connection of a single guild and plays its queue of tracks one after another.
+type player struct {
+	ctx     context.Context
+	client  *Client
+	guildID string
+
+	mtx sync.Mutex
+
+	voice     *voiceClient
+	channelID string
+
+	queue       []track
+	nextTrackID uint64
+	current     *track
+	running     bool
+	paused      bool
+	resumeCh    chan struct{}
+	skipTrack   context.CancelFunc
+	// changedCh is closed and replaced whenever the current track changes.
+	changedCh chan struct{}
+}
+
+func newPlayer(ctx context.Context, client *Client, guildID string) *player {
+	return &player{
+		ctx:       ctx,
+		client:    client,
+		guildID:   guildID,
+		changedCh: make(chan struct{}),
+	}
+}
+
+// Enqueue adds the track to the queue and starts playing if the player is idle.
+// It returns the position of the track in the queue, 0 meaning that it plays right away.
+func (p *player) Enqueue(t track, channelID string) int {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.nextTrackID++
+	t.id = p.nextTrackID
+
+	p.queue = append(p.queue, t)
+	if p.channelID == "" {
+		p.channelID = channelID
+	}
+
+	if !p.running {
+		p.running = true
+		go p.run()
+		return 0
+	}
+
+	return len(p.queue)
+}
+
+func (p *player) Skip() bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.current == nil {
+		return false
+	}
+
+	p.unpause()
+	p.skipTrack()
+
+	return true
+}
+
+// SkipTo drops the tracks queued before the track with the id and skips to it.
+func (p *player) SkipTo(id uint64) (track, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	position := -1
+	for i, t := range p.queue {
+		if t.id == id {
+			position = i
+			break
+		}
+	}
+
+	if position < 0 {
+		return track{}, fmt.Errorf("the selected track is no longer queued")
+	}
+
+	next := p.queue[position]
+	p.queue = p.queue[position:]
+
+	if p.current != nil {
+		p.unpause()
+		p.skipTrack()
+	}
+
+	return next, nil
+}
+
+func (p *player) Pause() bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.current == nil || p.paused {
+		return false
+	}
+
+	p.paused = true
+	p.resumeCh = make(chan struct{})
+
+	return true
+}
+
+func (p *player) Resume() bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if !p.paused {
+		return false
+	}
+
+	p.unpause()
+
+	return true
+}
+
+// Stop clears the queue, stops the current track and leaves the voice channel.
+func (p *player) Stop() {
+	p.mtx.Lock()
+
+	p.queue = nil
+	p.unpause()
+
+	if p.skipTrack != nil {
+		p.skipTrack()
+	}
+
+	voice := p.voice
+	p.voice = nil
+	p.channelID = ""
+	p.mtx.Unlock()
+
+	// Leaving the channel talks to the gateway, the player must stay usable meanwhile.
+	if voice != nil {
+		err := voice.Disconnect()
+		if err != nil {
+			log.Logger().WithError(err).Error("Could not leave the voice channel")
+		}
+	}
+}
+
+func (p *player) Paused() bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	return p.paused
+}
+
+// Changed returns a channel which is closed when the current track changes.
+func (p *player) Changed() <-chan struct{} {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	return p.changedCh
+}
+
+func (p *player) Queue() []track {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	queue := make([]track, len(p.queue))
+	copy(queue, p.queue)
+
+	return queue
+}
+
+func (p *player) NowPlaying() (track, bool) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.current == nil {
+		return track{}, false
+	}
+
+	return *p.current, true
+}
+
+// Remove removes the track at the given 1-based position of the queue.
+func (p *player) Remove(position int) (track, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if position < 1 || position > len(p.queue) {
+		return track{}, fmt.Errorf("there is no track at position %d", position)
+	}
+
+	removed := p.queue[position-1]
+	p.queue = append(p.queue[:position-1], p.queue[position:]...)
+
+	return removed, nil
+}
+
+func (p *player) Clear() int {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	cleared := len(p.queue)
+	p.queue = nil
+
+	return cleared
+}
+
+func (p *player) GetVoiceClient() *voiceClient {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	return p.voice
+}
+
+// notifyChanged must be called with mtx held.
+func (p *player) notifyChanged() {
+	close(p.changedCh)
+	p.changedCh = make(chan struct{})
+}
+
+// unpause must be called with mtx held.
+func (p *player) unpause() {
+	if !p.paused {
+		return
+	}
+
+	p.paused = false
+	close(p.resumeCh)
+}
+
+func (p *player) waitWhilePaused(ctx context.Context) error {
+	p.mtx.Lock()
+	if !p.paused {
+		p.mtx.Unlock()
+		return nil
+	}
+	resumeCh := p.resumeCh
+	p.mtx.Unlock()
+
+	select {
+	case <-resumeCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *player) run() {
+	for {
+		p.mtx.Lock()
+		if len(p.queue) == 0 {
+			p.current = nil
+			p.skipTrack = nil
+			p.running = false
+			p.notifyChanged()
+			p.mtx.Unlock()
+			return
+		}
+
+		current := p.queue[0]
+		p.queue = p.queue[1:]
+		p.current = &current
+		p.notifyChanged()
+
+		ctx, cancel := context.WithCancel(p.ctx)
+		p.skipTrack = cancel
+		p.mtx.Unlock()
+
+		log.Logger().WithField("guild_id", p.guildID).WithField("track", current.name).Info("Playing track")
+
+		err := p.play(ctx, current)
+		cancel()
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.Logger().WithError(err).WithField("track", current.name).Error("Could not play track")
+		}
+	}
+}
+
+func (p *player) play(ctx context.Context, t track) error {
+	source, err := audio.OpenOggOpusFile(t.path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	voice, err := p.connect()
+	if err != nil {
+		return err
+	}
+
+	err = voice.Play(ctx, &pausableSource{Source: source, ctx: ctx, player: p})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		// The voice connection is most likely broken, the next track will establish a new one.
+		p.mtx.Lock()
+		if p.voice == voice {
+			p.voice = nil
+		}
+		p.mtx.Unlock()
+		voice.Close()
+	}
+
+	return err
+}
+
+func (p *player) connect() (*voiceClient, error) {
+	p.mtx.Lock()
+	if p.voice != nil {
+		voice := p.voice
+		p.mtx.Unlock()
+		return voice, nil
+	}
+
+	voice := NewVoiceClient(p.ctx, p.client)
+	p.voice = voice
+	channelID := p.channelID
+	p.mtx.Unlock()
+
+	err := voice.ConnectToVoiceChannel(p.guildID, channelID, false, false)
+	if err != nil {
+		p.mtx.Lock()
+		if p.voice == voice {
+			p.voice = nil
+		}
+		p.mtx.Unlock()
+		voice.Close()
+		return nil, err
+	}
+
+	return voice, nil
+}
+
+// pausableSource blocks reading frames while the player is paused.
+type pausableSource struct {
+	audio.Source
+
+	ctx    context.Context
+	player *player
+}
+
+func (s *pausableSource) NextFrame() ([]byte, error) {
+	err := s.player.waitWhilePaused(s.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Source.NextFrame()
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bsponge/discordGopher/pkg/interaction"
+	"github.com/bsponge/discordGopher/pkg/object"
+	"github.com/bsponge/discordGopher/pkg/rest"
+)
+
+const (
+	playerComponent = "player"
+
+	pauseAction  = "pause"
+	resumeAction = "resume"
+	skipAction   = "skip"
+	stopAction   = "stop"
+	skipToAction = "skipto"
+
+	selectLabelLimit = 100
+	// trackChangeTimeout bounds the wait for the next track, so that the update fits in the interaction deadline.
+	trackChangeTimeout = time.Second
+)
+
+// playerMessage renders the current track together with the controls of the player.
+func playerMessage(p *player) (*rest.MessageCreateParams, error) {
+	builder := object.NewEmbedBuilder().Title("Nothing is playing").Color(embedColor)
+	if t, ok := p.NowPlaying(); ok {
+		builder = nowPlayingEmbed(t)
+	}
+
+	params, err := embedResponse(builder)
+	if err != nil {
+		return nil, err
+	}
+
+	params.Components = playerControls(p)
+
+	return params, nil
+}
+
+func playerControls(p *player) []object.Component {
+	_, playing := p.NowPlaying()
+
+	toggle := object.Button(object.SecondaryButton, "Pause", interaction.CustomID(playerComponent, pauseAction))
+	if p.Paused() {
+		toggle = object.Button(object.PrimaryButton, "Resume", interaction.CustomID(playerComponent, resumeAction))
+	}
+
+	buttons := []object.Component{
+		toggle,
+		object.Button(object.SecondaryButton, "Skip", interaction.CustomID(playerComponent, skipAction)),
+		object.Button(object.DangerButton, "Stop", interaction.CustomID(playerComponent, stopAction)),
+	}
+
+	if !playing {
+		for i := range buttons {
+			buttons[i].Disabled = true
+		}
+	}
+
+	components := []object.Component{object.ActionRow(buttons...)}
+
+	queue := p.Queue()
+	if !playing || len(queue) == 0 {
+		return components
+	}
+
+	options := make([]object.SelectOption, 0, object.SelectOptionsLimit)
+	for i, t := range queue {
+		if i == object.SelectOptionsLimit {
+			break
+		}
+
+		options = append(options, object.SelectOption{
+			Label: truncate(fmt.Sprintf("%d. %s", i+1, t.name), selectLabelLimit),
+			Value: strconv.FormatUint(t.id, 10),
+		})
+	}
+
+	trackSelect := object.StringSelect(interaction.CustomID(playerComponent, skipToAction), "Skip to...", options...)
+
+	return append(components, object.ActionRow(trackSelect))
+}
+
+func (c *Client) registerPlayerControls() {
+	c.interactions.Component(playerComponent, c.handlePlayerComponent)
+}
+
+// handlePlayerComponent runs the action of the pressed control and refreshes the message it is attached to.
+func (c *Client) handlePlayerComponent(ctx context.Context, event *interaction.Event) error {
+	if event.GuildID == nil {
+		return fmt.Errorf("the player can only be controlled in a server")
+	}
+
+	p := c.getPlayer(*event.GuildID)
+	changed := p.Changed()
+
+	switch action := event.Param(0); action {
+	case pauseAction:
+		p.Pause()
+	case resumeAction:
+		p.Resume()
+	case skipAction:
+		if p.Skip() {
+			waitForTrackChange(changed)
+		}
+	case stopAction:
+		p.Stop()
+		waitForTrackChange(changed)
+	case skipToAction:
+		values := event.Values()
+		if len(values) == 0 {
+			return fmt.Errorf("no track was selected")
+		}
+
+		id, err := strconv.ParseUint(values[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid track %s", values[0])
+		}
+
+		_, err = p.SkipTo(id)
+		if err != nil {
+			return err
+		}
+
+		waitForTrackChange(changed)
+	default:
+		return fmt.Errorf("unknown player action %s", action)
+	}
+
+	response, err := playerMessage(p)
+	if err != nil {
+		return err
+	}
+
+	return event.Update(ctx, callbackData(response))
+}
+
+func waitForTrackChange(changed <-chan struct{}) {
+	select {
+	case <-changed:
+	case <-time.After(trackChangeTimeout):
+	}
+}
+
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+
+	return string(runes[:limit-1]) + "…"
+}
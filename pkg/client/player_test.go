@@ -0,0 +1,33 @@
+package client
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPlayerSkipToFollowsTrackAfterQueueChanges(t *testing.T) {
+	p := newPlayer(context.Background(), nil, "1")
+	p.queue = []track{{id: 1, path: "a.ogg"}, {id: 2, path: "b.ogg"}, {id: 3, path: "c.ogg"}}
+
+	// The select menu was rendered with c.ogg at position 3, then a track before it was removed.
+	_, err := p.Remove(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := p.SkipTo(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.path != "c.ogg" {
+		t.Fatalf("skipped to %s, want c.ogg", next.path)
+	}
+	if len(p.queue) != 1 || p.queue[0].id != 3 {
+		t.Fatalf("got queue %+v, want only c.ogg", p.queue)
+	}
+
+	_, err = p.SkipTo(1)
+	if err == nil {
+		t.Fatal("expected an error for a track which is no longer queued")
+	}
+}
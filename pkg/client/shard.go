@@ -0,0 +1,591 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/bsponge/discordGopher/pkg/log"
+	"github.com/bsponge/discordGopher/pkg/object"
+
+	"nhooyr.io/websocket"
+)
+
+type ShardState string
+
+const (
+	ShardDisconnected ShardState = "disconnected"
+	ShardConnecting   ShardState = "connecting"
+	ShardResuming     ShardState = "resuming"
+	ShardReady        ShardState = "ready"
+	// ShardFailed means that the shard was closed with an error it cannot recover from, see LastError.
+	ShardFailed ShardState = "failed"
+)
+
+const (
+	reconnectMinBackoff = time.Second
+	reconnectMaxBackoff = 2 * time.Minute
+)
+
+// errShardClosed is returned when a closed shard is started, e.g. by a reconnect racing with a reshard.
+var errShardClosed = errors.New("the shard has been closed")
+
+type ShardStatus struct {
+	ID        int
+	Count     int
+	State     ShardState
+	SessionID string
+	// Latency is the time between the last heartbeat and its acknowledgement.
+	Latency   time.Duration
+	LastError error
+}
+
+// shard is a single gateway connection receiving the events of the guilds with (guild_id >> 22) % count == id.
+type shard struct {
+	parentCtx context.Context
+
+	mtx sync.Mutex
+
+	// cancel and gatewayWebsocket belong to the current connection.
+	cancel context.CancelFunc
+	// closed is set once the shard is stopped for good, it is never started again afterwards.
+	closed bool
+
+	id         int
+	count      int
+	client     *Client
+	manager    *shardManager
+	gatewayURL string
+
+	sequence int
+
+	gatewayWebsocket *websocket.Conn
+	resumeGatewayURL *url.URL
+	sessionID        string
+	// replayed counts the dispatches Discord replays after a resume, until RESUMED arrives.
+	replayed int
+
+	hbService *heartbeatService
+	inflater  *inflater
+
+	state     ShardState
+	latency   time.Duration
+	lastError error
+}
+
+func newShard(ctx context.Context, manager *shardManager, id int, count int, gatewayURL string) *shard {
+	s := &shard{
+		parentCtx:  ctx,
+		id:         id,
+		count:      count,
+		client:     manager.client,
+		manager:    manager,
+		gatewayURL: gatewayURL,
+		state:      ShardDisconnected,
+	}
+
+	s.hbService = NewHeartbeatService(s)
+
+	return s
+}
+
+// start connects the shard. When resuming, the session is resumed instead of identifying, Discord then replays
+// the dispatches missed since the last sequence.
+func (s *shard) start(ctx context.Context, resuming bool) error {
+	s.mtx.Lock()
+	if s.closed {
+		s.mtx.Unlock()
+		return errShardClosed
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.mtx.Unlock()
+
+	if resuming {
+		s.setState(ShardResuming)
+	} else {
+		s.setState(ShardConnecting)
+
+		// The slot is taken before connecting, as the identify has to follow Hello without delay.
+		err := s.manager.identify.wait(ctx, s.id)
+		if err != nil {
+			return err
+		}
+	}
+
+	ws, err := s.dial(ctx)
+	if err != nil {
+		// Nothing was identified on the connection, so the session start is not used up.
+		if !resuming {
+			s.manager.identify.release(s.id)
+		}
+		return err
+	}
+
+	// GUILD_CREATE payloads of big guilds easily exceed the default limit.
+	ws.SetReadLimit(gatewayReadLimit)
+
+	s.mtx.Lock()
+	// The shard was stopped while dialing.
+	if ctx.Err() != nil {
+		s.mtx.Unlock()
+		ws.Close(websocket.StatusInternalError, "")
+		if !resuming {
+			s.manager.identify.release(s.id)
+		}
+		return ctx.Err()
+	}
+
+	// Every connection starts a new compression context.
+	var inflater *inflater
+	if s.client.cfg.Compress != "" {
+		inflater = newInflater(s.client.cfg.Compress)
+	}
+
+	s.gatewayWebsocket = ws
+	s.inflater = inflater
+	s.mtx.Unlock()
+
+	go s.poolMessages(ctx, ws, resuming, inflater)
+
+	return nil
+}
+
+func (s *shard) dial(ctx context.Context) (*websocket.Conn, error) {
+	gatewayURL, err := s.getGatewayURL()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Logger().WithField("shard", s.id).Infof("Gateway URL: %s", gatewayURL)
+
+	ws, _, err := websocket.Dial(ctx, gatewayURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return ws, nil
+}
+
+func (s *shard) GetSequence() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.sequence
+}
+
+func (s *shard) setSequence(sequence int) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.sequence = sequence
+}
+
+func (s *shard) setState(state ShardState) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.state = state
+}
+
+func (s *shard) fail(err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.state = ShardFailed
+	s.lastError = err
+}
+
+func (s *shard) setLatency(latency time.Duration) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.latency = latency
+}
+
+func (s *shard) status() ShardStatus {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return ShardStatus{
+		ID:        s.id,
+		Count:     s.count,
+		State:     s.state,
+		SessionID: s.sessionID,
+		Latency:   s.latency,
+		LastError: s.lastError,
+	}
+}
+
+func (s *shard) poolMessages(ctx context.Context, ws *websocket.Conn, resuming bool, inflater *inflater) {
+	for {
+		_, body, err := ws.Read(ctx)
+		var closeError websocket.CloseError
+		switch {
+		case errors.As(err, &closeError):
+			log.Logger().WithField("shard", s.id).WithError(err).Error("The websocket connection was closed")
+			// A shard outside of the shard count or too few shards, the shard would be rejected again on reconnect.
+			if code := int(closeError.Code); code == object.ShardingRequired || code == object.InvalidShard {
+				s.fail(err)
+				go s.manager.reshard()
+				return
+			}
+
+			shouldReconnect, ok := object.ReconnectOnError[int(closeError.Code)]
+			if !ok || !shouldReconnect {
+				s.fail(err)
+				return
+			}
+
+			code := int(closeError.Code)
+			if code == object.InvalidSeq || code == object.SessionTimedOut {
+				s.reidentify()
+				return
+			}
+
+			s.resumeConnection()
+			return
+		case err != nil:
+			if ctx.Err() != nil {
+				return
+			}
+
+			// The connection dropped without a close frame, the session can still be resumed.
+			log.Logger().WithField("shard", s.id).WithError(err).Error("Could not read message from gateway wss")
+			s.resumeConnection()
+			return
+		default:
+		}
+
+		if inflater != nil {
+			body, err = inflater.inflate(ctx, body)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Logger().WithField("shard", s.id).WithError(err).Error("Could not decompress message received from gateway wss")
+					s.fail(err)
+				}
+				return
+			}
+			if body == nil {
+				continue
+			}
+		}
+
+		log.Logger().WithField("shard", s.id).Trace(string(body))
+
+		message, err := s.client.codec.decode(body)
+		if err != nil {
+			log.Logger().WithField("shard", s.id).WithError(err).Error("Could not parse message received from gateway wss")
+			s.fail(err)
+			return
+		}
+
+		// Only dispatches have a sequence, the other messages must not reset the one used to resume.
+		if message.sequence != nil {
+			s.setSequence(*message.sequence)
+		}
+
+		switch message.op {
+		case 0: // Dispatch (most Gateway events which represent actions taking place in a guild)
+			err := s.handleDispatch(object.Dispatch(message.dispatch), message.data)
+			if err != nil {
+				log.Logger().WithField("shard", s.id).WithError(err).WithField("dispatch_type", message.dispatch).Error("Could not handle dispatch")
+			}
+		case 1: // Extra heartbeat
+			err := s.hbService.SendHeartbeat(ws)
+			if err != nil {
+				log.Logger().WithField("shard", s.id).WithError(err).Error("Could not send heartbeat after receiving op code 1")
+			}
+		case 7: // Reconnect
+			s.resumeConnection()
+			return
+		case 9: // Invalid session
+			var resumable bool
+			err := s.client.codec.unmarshal(message.data, &resumable)
+			if err != nil {
+				log.Logger().WithField("shard", s.id).WithError(err).Error("Could not parse invalid session")
+			}
+			if resumable {
+				s.resumeConnection()
+			} else {
+				s.reidentify()
+			}
+			return
+		case 10: // Hello
+			var hello object.Hello
+			err := s.client.codec.unmarshal(message.data, &hello)
+			if err != nil {
+				log.Logger().WithField("shard", s.id).WithError(err).Error("Could not parse hello")
+				s.fail(err)
+				return
+			}
+
+			err = s.hbService.Start(ctx, ws, hello.HeartbeatInterval, resuming)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Logger().WithField("shard", s.id).WithError(err).Error("Could not send first heartbeat")
+				s.resumeConnection()
+				return
+			}
+		case 11: // Heartbeat ACK
+			s.setLatency(s.hbService.SinceLastHeartbeat())
+			log.Logger().WithField("shard", s.id).Trace("Received heartbeat ACK")
+		default:
+			log.Logger().WithField("shard", s.id).Trace("Unknown op code")
+		}
+	}
+}
+
+// handleDispatch keeps track of the session and passes the dispatch on to the client.
+func (s *shard) handleDispatch(dispatch object.Dispatch, payload []byte) error {
+	switch dispatch {
+	case object.ReadyType:
+		var ready object.Ready
+		err := s.client.codec.unmarshal(payload, &ready)
+		if err != nil {
+			return err
+		}
+
+		resumeURL, err := url.Parse(ready.ResumeGatewayURL)
+		if err != nil {
+			return err
+		}
+
+		s.mtx.Lock()
+		s.resumeGatewayURL = resumeURL
+		s.sessionID = ready.SessionID
+		s.state = ShardReady
+		s.mtx.Unlock()
+
+		log.Logger().WithField("shard", s.id).Info("The shard is ready")
+	case object.ResumedType:
+		s.mtx.Lock()
+		replayed := s.replayed
+		s.replayed = 0
+		s.state = ShardReady
+		s.mtx.Unlock()
+
+		log.Logger().WithField("shard", s.id).WithField("replayed", replayed).Info("The session has been resumed")
+	default:
+		// Replayed dispatches are handled like live ones, they are only counted for the log.
+		s.mtx.Lock()
+		if s.state == ShardResuming {
+			s.replayed++
+		}
+		s.mtx.Unlock()
+	}
+
+	return s.client.handleDispatch(dispatch, payload)
+}
+
+// resumeConnection reconnects and resumes the session, keeping its ID, sequence and resume gateway URL.
+func (s *shard) resumeConnection() {
+	s.Stop()
+
+	s.mtx.Lock()
+	sessionID := s.sessionID
+	sequence := s.sequence
+	s.replayed = 0
+	s.mtx.Unlock()
+
+	// The connection was lost before READY, there is no session to resume.
+	if sessionID == "" {
+		s.identifyAgain()
+		return
+	}
+
+	log.Logger().WithField("shard", s.id).WithField("session_id", sessionID).WithField("sequence", sequence).
+		Info("Resuming the session")
+
+	s.reconnect(true)
+}
+
+// reidentify drops the session Discord invalidated and starts a new one.
+func (s *shard) reidentify() {
+	s.Stop()
+
+	s.mtx.Lock()
+	s.sessionID = ""
+	s.sequence = 0
+	s.resumeGatewayURL = nil
+	s.mtx.Unlock()
+
+	// Discord asks to wait a random time between 1 and 5 seconds before identifying again.
+	delay := time.Second + time.Duration(rand.Int63n(int64(4*time.Second)))
+
+	log.Logger().WithField("shard", s.id).Infof("The session is no longer valid, identifying again in %s", delay)
+
+	select {
+	case <-time.After(delay):
+	case <-s.parentCtx.Done():
+		return
+	}
+
+	s.identifyAgain()
+}
+
+func (s *shard) identifyAgain() {
+	s.reconnect(false)
+}
+
+// reconnect starts the shard until it connects, waiting twice as long after every failure. The session is kept
+// between the attempts, only Discord invalidating it leads to a new identify.
+func (s *shard) reconnect(resuming bool) {
+	backoff := reconnectMinBackoff
+
+	for {
+		err := s.start(s.parentCtx, resuming)
+		if err == nil || errors.Is(err, errShardClosed) || s.parentCtx.Err() != nil {
+			return
+		}
+
+		log.Logger().WithField("shard", s.id).WithError(err).Errorf("Could not reconnect, retrying in %s", backoff)
+
+		s.mtx.Lock()
+		s.state = ShardDisconnected
+		s.lastError = err
+		s.mtx.Unlock()
+
+		select {
+		case <-time.After(backoff):
+		case <-s.parentCtx.Done():
+			return
+		}
+
+		backoff *= 2
+		if backoff > reconnectMaxBackoff {
+			backoff = reconnectMaxBackoff
+		}
+	}
+}
+
+func (s *shard) Identify(ctx context.Context, ws *websocket.Conn) error {
+	var intent int = 1         // GUILDS
+	intent = intent | (1 << 1) // GUILD_MEMBERS
+	intent = intent | (1 << 7) // GUILD_VOICE_STATES
+	intent = intent | (1 << 8) // GUILD_PRESENCES
+	intent = intent | (1 << 9) // GUILD_MESSAGES
+
+	identify := object.Identify{
+		Token: s.client.cfg.Token,
+		Properties: map[string]any{
+			"os":      runtime.GOOS,
+			"browser": "discordGopher",
+			"device":  "discordGopher",
+		},
+		// Payload compression is not used, the whole connection is compressed instead when configured.
+		Compress: false,
+		Intents:  intent,
+		Shard:    &[2]int{s.id, s.count},
+	}
+
+	event := object.Event[object.Identify]{
+		Op: 2,
+		D:  identify,
+	}
+
+	err := s.write(ctx, ws, event)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *shard) Resume(ctx context.Context, ws *websocket.Conn) error {
+	s.mtx.Lock()
+	resume := object.Resume{
+		Token:     s.client.cfg.Token,
+		SessionID: s.sessionID,
+		Sequence:  s.sequence,
+	}
+	s.mtx.Unlock()
+
+	event := object.Event[object.Resume]{
+		Op: 6,
+		D:  resume,
+	}
+
+	return s.write(ctx, ws, event)
+}
+
+// send writes a gateway command, e.g. a voice state update, to the connection of the shard.
+func (s *shard) send(ctx context.Context, event any) error {
+	s.mtx.Lock()
+	ws := s.gatewayWebsocket
+	s.mtx.Unlock()
+
+	if ws == nil {
+		return fmt.Errorf("shard %d is not connected", s.id)
+	}
+
+	return s.write(ctx, ws, event)
+}
+
+// write encodes the event with the encoding of the connection.
+func (s *shard) write(ctx context.Context, ws *websocket.Conn, event any) error {
+	data, err := s.client.codec.marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return ws.Write(ctx, s.client.codec.messageType(), data)
+}
+
+// getGatewayURL returns the URL to resume the session on, or the one shared by all shards for new sessions.
+func (s *shard) getGatewayURL() (string, error) {
+	s.mtx.Lock()
+	resumeGatewayURL := s.resumeGatewayURL
+	s.mtx.Unlock()
+
+	if resumeGatewayURL != nil {
+		return gatewayURL(resumeGatewayURL.String(), s.client.codec.encoding(), s.client.cfg.Compress)
+	}
+
+	return s.gatewayURL, nil
+}
+
+// close stops the shard for good, a reconnect in progress does not start it again.
+func (s *shard) close() {
+	s.mtx.Lock()
+	s.closed = true
+	s.mtx.Unlock()
+
+	s.Stop()
+}
+
+func (s *shard) Stop() {
+	log.Logger().WithField("shard", s.id).Info("Stopping the shard")
+
+	s.mtx.Lock()
+	cancel := s.cancel
+	ws := s.gatewayWebsocket
+	inflater := s.inflater
+	s.gatewayWebsocket = nil
+	s.inflater = nil
+	s.mtx.Unlock()
+
+	// The context is cancelled first, so that the read loop does not take the closed connection for a dropped one.
+	// Closing with a status other than 1000 keeps the session resumable.
+	if cancel != nil {
+		cancel()
+	}
+	if ws != nil {
+		ws.Close(websocket.StatusInternalError, "")
+	}
+
+	if inflater != nil {
+		inflater.Close()
+	}
+
+	s.hbService.Stop()
+	s.setState(ShardDisconnected)
+
+	log.Logger().WithField("shard", s.id).Info("The shard has stopped")
+}
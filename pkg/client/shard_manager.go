@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bsponge/discordGopher/pkg/log"
+)
+
+// shardManager runs one gateway connection per shard and routes guild operations to the shard of the guild.
+type shardManager struct {
+	ctx      context.Context
+	client   *Client
+	identify *identifyLimiter
+
+	mtx        sync.RWMutex
+	shards     []*shard
+	resharding bool
+}
+
+func newShardManager(client *Client) *shardManager {
+	return &shardManager{
+		client:   client,
+		identify: newIdentifyLimiter(),
+	}
+}
+
+// start connects all shards. With count 0 the number of shards recommended by Discord is used.
+func (m *shardManager) start(ctx context.Context, count int) error {
+	m.ctx = ctx
+
+	gateway, err := m.client.rest.GetGatewayBot(ctx)
+	if err != nil {
+		return fmt.Errorf("could not obtain gateway url: %w", err)
+	}
+
+	if gateway.URL == "" {
+		return fmt.Errorf("could not obtain gateway url from received response")
+	}
+
+	if count == 0 {
+		count = gateway.Shards
+	}
+	if count < 1 {
+		count = 1
+	}
+
+	limit := gateway.SessionStartLimit
+	log.Logger().WithField("shards", count).WithField("session_starts_remaining", limit.Remaining).
+		WithField("session_starts_total", limit.Total).Info("Starting shards")
+
+	// The shards which do not fit in the remaining session starts wait for the limit to reset, see identifyLimiter.
+	if limit.Remaining < count {
+		log.Logger().WithField("shards", count).WithField("session_starts_remaining", limit.Remaining).
+			Warnf("Not enough session starts are left, some shards start once the limit resets in %s",
+				time.Duration(limit.ResetAfter)*time.Millisecond)
+	}
+
+	m.identify.update(limit)
+
+	gatewayURL, err := gatewayURL(gateway.URL, m.client.codec.encoding(), m.client.cfg.Compress)
+	if err != nil {
+		return err
+	}
+
+	shards := make([]*shard, count)
+	for i := range shards {
+		shards[i] = newShard(ctx, m, i, count, gatewayURL)
+	}
+
+	m.mtx.Lock()
+	m.shards = shards
+	m.mtx.Unlock()
+
+	// The shards are started one by one, each of them waits for its identify slot.
+	for i, s := range shards {
+		err := s.start(ctx, false)
+		if err != nil {
+			return fmt.Errorf("could not start shard %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (m *shardManager) stop() {
+	m.mtx.RLock()
+	shards := m.shards
+	m.mtx.RUnlock()
+
+	for _, s := range shards {
+		s.close()
+	}
+}
+
+// reshard restarts all shards with the recommended shard count after Discord closed a connection
+// because the bot needs more shards or the shard count is invalid.
+func (m *shardManager) reshard() {
+	m.mtx.Lock()
+	if m.resharding {
+		m.mtx.Unlock()
+		return
+	}
+	m.resharding = true
+	m.mtx.Unlock()
+
+	defer func() {
+		m.mtx.Lock()
+		m.resharding = false
+		m.mtx.Unlock()
+	}()
+
+	log.Logger().Warn("Discord rejected the shard count, restarting all shards with the recommended count")
+
+	m.stop()
+
+	err := m.start(m.ctx, 0)
+	if err != nil {
+		log.Logger().WithError(err).Error("Could not restart the shards")
+	}
+}
+
+// shardFor returns the shard receiving the events of the guild, nil if the shards have not been started.
+func (m *shardManager) shardFor(guildID string) *shard {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	if len(m.shards) == 0 {
+		return nil
+	}
+
+	id, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		return m.shards[0]
+	}
+
+	return m.shards[(id>>22)%uint64(len(m.shards))]
+}
+
+func (m *shardManager) statuses() []ShardStatus {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	statuses := make([]ShardStatus, 0, len(m.shards))
+	for _, s := range m.shards {
+		statuses = append(statuses, s.status())
+	}
+
+	return statuses
+}
+
+// gatewayURL adds the API version, the encoding and the transport compression to the gateway URL.
+func gatewayURL(rawURL string, encoding string, compression string) (string, error) {
+	err := checkCompression(compression)
+	if err != nil {
+		return "", err
+	}
+
+	url, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	values := url.Query()
+
+	values.Set(apiVersionKey, apiVersionValue)
+	values.Set(encodingKey, encoding)
+	if compression != "" {
+		values.Set(compressKey, compression)
+	}
+
+	url.RawQuery = values.Encode()
+
+	return url.String(), nil
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bsponge/discordGopher/pkg/config"
+	"github.com/bsponge/discordGopher/pkg/object"
+	"github.com/bsponge/discordGopher/pkg/rest"
+	"nhooyr.io/websocket"
+)
+
+func TestClosedShardIsNotStartedAgain(t *testing.T) {
+	s := &shard{id: 1, state: ShardDisconnected}
+	s.hbService = NewHeartbeatService(s)
+
+	s.close()
+
+	// A reconnect which was already in progress when the manager closed the shard.
+	err := s.start(context.Background(), true)
+	if !errors.Is(err, errShardClosed) {
+		t.Fatalf("got error %v, want %v", err, errShardClosed)
+	}
+	if state := s.status().State; state != ShardDisconnected {
+		t.Fatalf("got state %s, want %s", state, ShardDisconnected)
+	}
+}
+
+func TestInvalidShardCloseReshards(t *testing.T) {
+	gatewayRequested := make(chan struct{}, 1)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/gateway/bot") {
+			select {
+			case gatewayRequested <- struct{}{}:
+			default:
+			}
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer api.Close()
+
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		ws.Close(websocket.StatusCode(object.InvalidShard), "Invalid shard.")
+	}))
+	defer gateway.Close()
+
+	c := &Client{
+		cfg:   &config.Config{},
+		rest:  rest.NewClient("token", rest.WithBaseURL(api.URL)),
+		codec: jsonCodec{},
+	}
+	m := newShardManager(c)
+	m.ctx = context.Background()
+
+	s := newShard(context.Background(), m, 3, 2, gateway.URL)
+	m.shards = []*shard{s}
+
+	ws, _, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(gateway.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.poolMessages(context.Background(), ws, false, nil)
+
+	if state := s.status().State; state != ShardFailed {
+		t.Fatalf("got state %s, want %s", state, ShardFailed)
+	}
+
+	select {
+	case <-gatewayRequested:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the shards to be restarted with the recommended count")
+	}
+}
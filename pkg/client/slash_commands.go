@@ -0,0 +1,192 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/bsponge/discordGopher/pkg/interaction"
+	"github.com/bsponge/discordGopher/pkg/log"
+	"github.com/bsponge/discordGopher/pkg/object"
+	"github.com/bsponge/discordGopher/pkg/rest"
+)
+
+const (
+	trackOption = "track"
+
+	// choiceLimit is the maximum length of the name and the value of a choice.
+	choiceLimit = 100
+)
+
+// playerCommands are the slash command counterparts of the text commands.
+var playerCommands = []object.ApplicationCommand{
+	{
+		Name:        playCommand,
+		Description: "Play a track or add it to the queue",
+		Options: []object.ApplicationCommandOption{
+			{
+				Type:         object.StringOption,
+				Name:         trackOption,
+				Description:  "Path of the track to play",
+				Required:     true,
+				Autocomplete: true,
+			},
+		},
+	},
+	{Name: skipCommand, Description: "Skip the current track"},
+	{Name: pauseCommand, Description: "Pause the playback"},
+	{Name: resumeCommand, Description: "Resume the playback"},
+	{Name: stopCommand, Description: "Stop the playback and clear the queue"},
+	{Name: queueCommand, Description: "Show the queue"},
+	{Name: nowPlayingCommand, Description: "Show the current track"},
+	{
+		Name:        removeCommand,
+		Description: "Remove a track from the queue",
+		Options: []object.ApplicationCommandOption{
+			{
+				Type:        object.IntegerOption,
+				Name:        "position",
+				Description: "Position of the track in the queue",
+				Required:    true,
+			},
+		},
+	},
+	{Name: clearCommand, Description: "Remove all tracks from the queue"},
+}
+
+func (c *Client) registerPlayerCommands() {
+	for _, command := range playerCommands {
+		c.interactions.Command(command.Name, c.handleSlashCommand)
+	}
+
+	c.interactions.Autocomplete(playCommand, trackOption, c.autocompleteTrack)
+}
+
+// syncPlayerCommands registers the player commands in the configured server, or globally if there is none.
+// Global commands take up to an hour to show up, guild commands are available immediately.
+func (c *Client) syncPlayerCommands() {
+	err := c.SyncCommands(c.parentCtx, c.cfg.ServerID, playerCommands)
+	if err != nil {
+		log.Logger().WithError(err).Error("Could not sync slash commands")
+	}
+}
+
+func (c *Client) handleSlashCommand(ctx context.Context, event *interaction.Event) error {
+	if event.GuildID == nil {
+		return fmt.Errorf("this command can only be used in a server")
+	}
+
+	user := event.Invoker()
+	if user == nil {
+		return fmt.Errorf("could not determine who used the command")
+	}
+
+	var args []string
+	for _, option := range event.Options() {
+		var value any
+		err := json.Unmarshal(option.Value, &value)
+		if err != nil {
+			return fmt.Errorf("invalid %s option: %w", option.Name, err)
+		}
+
+		args = append(args, fmt.Sprint(value))
+	}
+
+	// The command waits in the queue of the guild so that it runs in order with the text commands. The queue may
+	// not get to it within the 3 seconds Discord gives to respond, so the interaction is acknowledged first.
+	err := event.Defer(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	guildID := *event.GuildID
+	command := event.Data.Name
+
+	c.getCommandQueue(guildID).push(func() {
+		response, err := c.runCommand(guildID, user, command, args)
+		if err == nil && response == nil {
+			err = fmt.Errorf("unknown command %s", command)
+		}
+		if err != nil {
+			log.Logger().WithError(err).WithField("command", command).WithField("user", user.Username).Error("Command failed")
+			response = textResponse(err.Error())
+		}
+
+		_, err = event.EditOriginal(ctx, editParams(response))
+		if err != nil {
+			log.Logger().WithError(err).WithField("command", command).Error("Could not reply to the command")
+		}
+	})
+
+	return nil
+}
+
+// callbackData turns the reply of a command into an interaction response which does not ping anyone.
+func callbackData(params *rest.MessageCreateParams) *object.InteractionCallbackData {
+	return &object.InteractionCallbackData{
+		Content:    params.Content,
+		Embeds:     params.Embeds,
+		Components: params.Components,
+		AllowedMentions: &object.AllowedMentions{
+			Parse: []object.AllowedMentionType{},
+		},
+	}
+}
+
+// editParams turns the reply of a command into the edit of a deferred response which does not ping anyone.
+func editParams(params *rest.MessageCreateParams) *rest.MessageEditParams {
+	return &rest.MessageEditParams{
+		Content:    &params.Content,
+		Embeds:     &params.Embeds,
+		Components: &params.Components,
+		AllowedMentions: &object.AllowedMentions{
+			Parse: []object.AllowedMentionType{},
+		},
+	}
+}
+
+// autocompleteTrack suggests the tracks of the library matching what the user has typed.
+func (c *Client) autocompleteTrack(ctx context.Context, event *interaction.Event, value string) ([]object.ApplicationCommandOptionChoice, error) {
+	if c.library == nil {
+		return nil, nil
+	}
+
+	tracks, err := c.library.Search(value, object.AutocompleteChoicesLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	choices := make([]object.ApplicationCommandOptionChoice, 0, len(tracks))
+	for _, track := range tracks {
+		// Values cannot be shortened without breaking the path.
+		if utf8.RuneCountInString(track) > choiceLimit {
+			continue
+		}
+
+		choices = append(choices, object.ApplicationCommandOptionChoice{
+			Name:  track,
+			Value: track,
+		})
+	}
+
+	return choices, nil
+}
+
+func (c *Client) handleInteractionCreate(payload []byte) error {
+	var i object.Interaction
+	err := c.codec.unmarshal(payload, &i)
+	if err != nil {
+		return err
+	}
+
+	// Handlers talk to the REST API, so they must not block the gateway read loop.
+	go func() {
+		err := c.interactions.Handle(c.parentCtx, &i, interaction.RESTResponder(c.rest, &i))
+		if err != nil {
+			log.Logger().WithError(err).WithField("interaction_id", i.ID).Error("Could not handle interaction")
+		}
+	}()
+
+	return nil
+}
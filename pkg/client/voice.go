@@ -2,44 +2,79 @@ package client
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/bsponge/discordGopher/pkg/audio"
+	"github.com/bsponge/discordGopher/pkg/log"
 	"github.com/bsponge/discordGopher/pkg/object"
+
+	"github.com/valyala/fastjson"
 	"nhooyr.io/websocket"
 	"nhooyr.io/websocket/wsjson"
 )
 
+const (
+	voiceGatewayVersion = "4"
+	voiceProtocol       = "udp"
+
+	silenceFramesOnStop = 5
+	framesBufferSize    = 5
+)
+
 type voiceClient struct {
-	ctx context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	client *Client
 
-	client         *Client
+	// mtx guards the connections, which are set up by the read loop and closed from the player.
+	mtx            sync.Mutex
 	voiceWebsocket *websocket.Conn
+	udpTransport   *voiceUDPTransport
+	stopHeartbeat  context.CancelFunc
+	closed         bool
 
 	voiceServerUpdateCh chan object.VoiceServerUpdate
 	voiceStateCh        chan object.VoiceState
+	sessionReadyCh      chan struct{}
+	sessionReadyOnce    sync.Once
+	errCh               chan error
+
+	guildID            string
+	ssrc               uint32
+	sessionDescription object.VoiceSessionDescription
 }
 
 func NewVoiceClient(ctx context.Context, client *Client) *voiceClient {
+	ctx, cancel := context.WithCancel(ctx)
+
 	return &voiceClient{
-		ctx:    ctx,
-		client: client,
+		ctx:                 ctx,
+		cancel:              cancel,
+		client:              client,
+		voiceServerUpdateCh: make(chan object.VoiceServerUpdate, 1),
+		voiceStateCh:        make(chan object.VoiceState, 1),
+		sessionReadyCh:      make(chan struct{}),
+		errCh:               make(chan error, 1),
 	}
 }
 
+// ConnectToVoiceChannel joins the voice channel and blocks until the voice session is ready to carry audio.
 func (c *voiceClient) ConnectToVoiceChannel(guildID string, channelID string, selfMute bool, selfDeaf bool) error {
-	voiceEvent := object.Event[object.VoiceState]{
-		Op: 4,
-		D: object.VoiceState{
-			GuildID:   &guildID,
-			ChannelID: &channelID,
-			SelfMute:  &selfMute,
-			SelfDeaf:  &selfDeaf,
-		},
-	}
-
-	c.voiceServerUpdateCh = make(chan object.VoiceServerUpdate)
-	c.voiceStateCh = make(chan object.VoiceState)
+	c.guildID = guildID
 
-	err := wsjson.Write(c.ctx, c.client.gatewayWebsocket, voiceEvent)
+	err := c.client.updateVoiceState(c.ctx, object.VoiceStateUpdate{
+		GuildID:   guildID,
+		ChannelID: &channelID,
+		SelfMute:  selfMute,
+		SelfDeaf:  selfDeaf,
+	})
 	if err != nil {
 		return err
 	}
@@ -47,22 +82,32 @@ func (c *voiceClient) ConnectToVoiceChannel(guildID string, channelID string, se
 	var voiceServerUpdate object.VoiceServerUpdate
 	var voiceState object.VoiceState
 
-	select {
-	case voiceServerUpdate = <-c.voiceServerUpdateCh:
-	case voiceState = <-c.voiceStateCh:
+	for i := 0; i < 2; i++ {
+		select {
+		case voiceServerUpdate = <-c.voiceServerUpdateCh:
+		case voiceState = <-c.voiceStateCh:
+		case <-c.ctx.Done():
+			return c.ctx.Err()
+		}
 	}
 
-	select {
-	case voiceServerUpdate = <-c.voiceServerUpdateCh:
-	case voiceState = <-c.voiceStateCh:
+	if voiceServerUpdate.Endpoint == "" {
+		return fmt.Errorf("voice server for guild %s is not available", guildID)
 	}
 
-	ws, _, err := websocket.Dial(c.ctx, voiceServerUpdate.Endpoint, nil)
+	ws, _, err := websocket.Dial(c.ctx, voiceEndpointURL(voiceServerUpdate.Endpoint), nil)
 	if err != nil {
 		return err
 	}
 
+	c.mtx.Lock()
+	if c.closed {
+		c.mtx.Unlock()
+		ws.Close(websocket.StatusNormalClosure, "")
+		return c.ctx.Err()
+	}
 	c.voiceWebsocket = ws
+	c.mtx.Unlock()
 
 	identifyEvent := object.Event[object.VoiceIdentify]{
 		Op: 0,
@@ -73,11 +118,368 @@ func (c *voiceClient) ConnectToVoiceChannel(guildID string, channelID string, se
 			Token:     voiceServerUpdate.Token,
 		},
 	}
-	_ = identifyEvent // TODO identify
+
+	err = wsjson.Write(c.ctx, ws, identifyEvent)
+	if err != nil {
+		return err
+	}
+
+	go c.poolMessages(ws)
+
+	select {
+	case <-c.sessionReadyCh:
+	case err := <-c.errCh:
+		return err
+	case <-c.ctx.Done():
+		return c.ctx.Err()
+	}
+
+	log.Logger().WithField("guild_id", guildID).WithField("mode", c.sessionDescription.Mode).Info("Voice session is ready")
+
+	return nil
+}
+
+func (c *voiceClient) poolMessages(ws *websocket.Conn) {
+	for {
+		_, body, err := ws.Read(c.ctx)
+		if err != nil {
+			c.fail(fmt.Errorf("could not read message from voice gateway wss: %w", err))
+			return
+		}
+
+		log.Logger().Trace(string(body))
+
+		resp, err := fastjson.ParseBytes(body)
+		if err != nil {
+			c.fail(fmt.Errorf("could not parse json received from voice gateway wss: %w", err))
+			return
+		}
+
+		var d []byte
+		d = resp.Get("d").MarshalTo(d)
+
+		switch resp.GetInt("op") {
+		case 2: // Ready
+			err = c.handleReady(ws, d)
+		case 4: // Session Description
+			err = c.handleSessionDescription(d)
+		case 6: // Heartbeat ACK
+			log.Logger().Trace("Received voice heartbeat ACK")
+		case 8: // Hello
+			err = c.handleHello(ws, d)
+		default:
+			log.Logger().Trace("Unknown voice op code")
+		}
+
+		if err != nil {
+			c.fail(err)
+			return
+		}
+	}
+}
+
+func (c *voiceClient) handleHello(ws *websocket.Conn, payload []byte) error {
+	var hello object.VoiceHello
+	err := json.Unmarshal(payload, &hello)
+	if err != nil {
+		return err
+	}
+
+	// Only the heartbeat of the latest Hello runs.
+	ctx, cancel := context.WithCancel(c.ctx)
+
+	c.mtx.Lock()
+	if c.stopHeartbeat != nil {
+		c.stopHeartbeat()
+	}
+	c.stopHeartbeat = cancel
+	c.mtx.Unlock()
+
+	go c.heartbeat(ctx, ws, time.Duration(hello.HeartbeatInterval*float64(time.Millisecond)))
+
+	return nil
+}
+
+func (c *voiceClient) heartbeat(ctx context.Context, ws *websocket.Conn, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		event := object.Event[int64]{
+			Op: 3,
+			D:  time.Now().UnixMilli(),
+		}
+
+		log.Logger().Trace("Sending voice heartbeat")
+
+		// A write cancelled midway closes the connection, so ctx only stops the loop.
+		err := wsjson.Write(c.ctx, ws, event)
+		if err != nil {
+			log.Logger().WithError(err).Error("Could not send voice heartbeat")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *voiceClient) handleReady(ws *websocket.Conn, payload []byte) error {
+	var ready object.VoiceReady
+	err := json.Unmarshal(payload, &ready)
+	if err != nil {
+		return err
+	}
+
+	c.ssrc = ready.SSRC
+
+	mode, err := selectVoiceMode(ready.Modes)
+	if err != nil {
+		return err
+	}
+
+	udpTransport, err := newVoiceUDPTransport(ready.IP, ready.Port, ready.SSRC)
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	if c.closed {
+		c.mtx.Unlock()
+		udpTransport.Close()
+		return c.ctx.Err()
+	}
+	c.udpTransport = udpTransport
+	c.mtx.Unlock()
+
+	address, port, err := udpTransport.DiscoverIP()
+	if err != nil {
+		return err
+	}
+
+	selectProtocolEvent := object.Event[object.VoiceSelectProtocol]{
+		Op: 1,
+		D: object.VoiceSelectProtocol{
+			Protocol: voiceProtocol,
+			Data: object.VoiceSelectProtocolData{
+				Address: address,
+				Port:    port,
+				Mode:    mode,
+			},
+		},
+	}
+
+	return wsjson.Write(c.ctx, ws, selectProtocolEvent)
+}
+
+func (c *voiceClient) handleSessionDescription(payload []byte) error {
+	err := json.Unmarshal(payload, &c.sessionDescription)
+	if err != nil {
+		return err
+	}
+
+	encrypter, err := newVoiceEncrypter(c.sessionDescription.Mode, c.sessionDescription.SecretKey)
+	if err != nil {
+		return err
+	}
+
+	c.getTransport().SetEncrypter(encrypter)
+
+	// Discord sends the session description again e.g. when the encryption mode changes.
+	c.sessionReadyOnce.Do(func() {
+		close(c.sessionReadyCh)
+	})
+
+	return nil
+}
+
+// Play streams the audio source to the voice channel and returns once the source is exhausted.
+func (c *voiceClient) Play(ctx context.Context, source audio.Source) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	frames := make(chan []byte, framesBufferSize)
+	readErrCh := make(chan error, 1)
+
+	go func() {
+		defer close(frames)
+
+		for {
+			frame, err := source.NextFrame()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				readErrCh <- err
+				return
+			}
+
+			select {
+			case frames <- frame:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	err := c.SendOpus(ctx, frames)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case err := <-readErrCh:
+		return fmt.Errorf("could not read audio source: %w", err)
+	default:
+	}
+
+	return nil
+}
+
+// SendOpus streams Opus frames to the voice server, each after the previous one has played, until frames is closed.
+func (c *voiceClient) SendOpus(ctx context.Context, frames <-chan []byte) error {
+	err := c.setSpeaking(true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := c.setSpeaking(false)
+		if err != nil {
+			log.Logger().WithError(err).Error("Could not stop speaking")
+		}
+	}()
+
+	ticker := time.NewTicker(opusFrameDuration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.ctx.Done():
+			return c.ctx.Err()
+		case <-ticker.C:
+		}
+
+		var frame []byte
+		var ok bool
+		select {
+		case frame, ok = <-frames:
+		default:
+			// The source fell behind, skip this tick rather than bursting later.
+			continue
+		}
+
+		if !ok {
+			return c.sendSilence()
+		}
+
+		duration, err := audio.PacketDuration(frame)
+		if err != nil {
+			return fmt.Errorf("could not send opus frame: %w", err)
+		}
+
+		samples, err := audio.PacketSamples(frame)
+		if err != nil {
+			return fmt.Errorf("could not send opus frame: %w", err)
+		}
+
+		err = c.getTransport().WriteFrame(frame, samples)
+		if err != nil {
+			return err
+		}
+
+		ticker.Reset(duration)
+	}
+}
+
+// sendSilence sends a few silence frames so the receivers do not interpolate the last frame.
+func (c *voiceClient) sendSilence() error {
+	for i := 0; i < silenceFramesOnStop; i++ {
+		err := c.getTransport().WriteFrame(opusSilenceFrame, opusSamplesPerFrame)
+		if err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+func (c *voiceClient) setSpeaking(speaking bool) error {
+	var flags int
+	if speaking {
+		flags = 1 // Microphone
+	}
+
+	speakingEvent := object.Event[object.VoiceSpeaking]{
+		Op: 5,
+		D: object.VoiceSpeaking{
+			Speaking: flags,
+			SSRC:     c.ssrc,
+		},
+	}
+
+	return wsjson.Write(c.ctx, c.getWebsocket(), speakingEvent)
+}
+
+func (c *voiceClient) fail(err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	log.Logger().WithError(err).Error("Voice connection failed")
+
+	select {
+	case c.errCh <- err:
+	default:
+	}
+}
+
+// Disconnect leaves the voice channel and closes the voice connection.
+func (c *voiceClient) Disconnect() error {
+	defer c.Close()
+
+	return c.client.updateVoiceState(c.ctx, object.VoiceStateUpdate{
+		GuildID: c.guildID,
+	})
+}
+
+func (c *voiceClient) Close() {
+	c.cancel()
+
+	c.mtx.Lock()
+	c.closed = true
+	ws := c.voiceWebsocket
+	udpTransport := c.udpTransport
+	c.mtx.Unlock()
+
+	if ws != nil {
+		ws.Close(websocket.StatusNormalClosure, "")
+	}
+
+	if udpTransport != nil {
+		udpTransport.Close()
+	}
+}
+
+// getWebsocket returns the voice gateway connection, it is set once ConnectToVoiceChannel has dialed it.
+func (c *voiceClient) getWebsocket() *websocket.Conn {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.voiceWebsocket
+}
+
+// getTransport returns the UDP connection, it is set once the voice server is ready.
+func (c *voiceClient) getTransport() *voiceUDPTransport {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.udpTransport
+}
+
 func (c *voiceClient) GetVoiceServerUpdateCh() chan object.VoiceServerUpdate {
 	return c.voiceServerUpdateCh
 }
@@ -85,3 +487,7 @@ func (c *voiceClient) GetVoiceServerUpdateCh() chan object.VoiceServerUpdate {
 func (c *voiceClient) GetVoiceStateCh() chan object.VoiceState {
 	return c.voiceStateCh
 }
+
+func voiceEndpointURL(endpoint string) string {
+	return fmt.Sprintf("wss://%s/?v=%s", strings.TrimPrefix(endpoint, "wss://"), voiceGatewayVersion)
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+const (
+	aeadAES256GCMRTPSize         = "aead_aes256_gcm_rtpsize"
+	aeadXChaCha20Poly1305RTPSize = "aead_xchacha20_poly1305_rtpsize"
+	xsalsa20Poly1305Lite         = "xsalsa20_poly1305_lite"
+	xsalsa20Poly1305Suffix       = "xsalsa20_poly1305_suffix"
+	xsalsa20Poly1305             = "xsalsa20_poly1305"
+
+	xsalsa20NonceSize = 24
+	nonceCounterSize  = 4
+)
+
+// voiceNonceReader provides the random nonces of xsalsa20_poly1305_suffix.
+var voiceNonceReader io.Reader = rand.Reader
+
+// supportedVoiceModes lists the encryption modes the client is able to use, in order of preference.
+var supportedVoiceModes = []string{
+	aeadAES256GCMRTPSize,
+	aeadXChaCha20Poly1305RTPSize,
+	xsalsa20Poly1305Lite,
+	xsalsa20Poly1305Suffix,
+	xsalsa20Poly1305,
+}
+
+// voiceEncrypter turns an RTP header and an Opus frame into a packet encrypted in the negotiated mode.
+type voiceEncrypter interface {
+	Mode() string
+	Seal(header []byte, payload []byte) []byte
+}
+
+func newVoiceEncrypter(mode string, key [32]byte) (voiceEncrypter, error) {
+	switch mode {
+	case aeadAES256GCMRTPSize:
+		block, err := aes.NewCipher(key[:])
+		if err != nil {
+			return nil, err
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rtpSizeEncrypter{mode: mode, aead: aead}, nil
+	case aeadXChaCha20Poly1305RTPSize:
+		aead, err := chacha20poly1305.NewX(key[:])
+		if err != nil {
+			return nil, err
+		}
+
+		return &rtpSizeEncrypter{mode: mode, aead: aead}, nil
+	case xsalsa20Poly1305Lite, xsalsa20Poly1305Suffix, xsalsa20Poly1305:
+		return &xsalsa20Encrypter{mode: mode, key: key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported voice encryption mode %s", mode)
+	}
+}
+
+func selectVoiceMode(modes []string) (string, error) {
+	for _, supported := range supportedVoiceModes {
+		for _, mode := range modes {
+			if mode == supported {
+				return mode, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("none of the offered voice encryption modes is supported: %v", modes)
+}
+
+// rtpSizeEncrypter implements the AEAD *_rtpsize modes. The RTP header is authenticated but
+// not encrypted and a 32 bit incrementing nonce is appended to every packet.
+type rtpSizeEncrypter struct {
+	mode  string
+	aead  cipher.AEAD
+	nonce uint32
+}
+
+func (e *rtpSizeEncrypter) Mode() string {
+	return e.mode
+}
+
+func (e *rtpSizeEncrypter) Seal(header []byte, payload []byte) []byte {
+	nonce := make([]byte, e.aead.NonceSize())
+	binary.BigEndian.PutUint32(nonce, e.nonce)
+	e.nonce++
+
+	packet := make([]byte, len(header), len(header)+len(payload)+e.aead.Overhead()+nonceCounterSize)
+	copy(packet, header)
+
+	packet = e.aead.Seal(packet, nonce, payload, header)
+
+	return append(packet, nonce[:nonceCounterSize]...)
+}
+
+// xsalsa20Encrypter implements the legacy xsalsa20_poly1305 modes which differ only in how the nonce is built.
+type xsalsa20Encrypter struct {
+	mode  string
+	key   [32]byte
+	nonce uint32
+}
+
+func (e *xsalsa20Encrypter) Mode() string {
+	return e.mode
+}
+
+func (e *xsalsa20Encrypter) Seal(header []byte, payload []byte) []byte {
+	var nonce [xsalsa20NonceSize]byte
+
+	packet := make([]byte, len(header), len(header)+len(payload)+secretbox.Overhead+xsalsa20NonceSize)
+	copy(packet, header)
+
+	switch e.mode {
+	case xsalsa20Poly1305Lite:
+		binary.BigEndian.PutUint32(nonce[:], e.nonce)
+		e.nonce++
+
+		packet = secretbox.Seal(packet, payload, &nonce, &e.key)
+		return append(packet, nonce[:nonceCounterSize]...)
+	case xsalsa20Poly1305Suffix:
+		// crypto/rand never fails on supported platforms.
+		_, _ = io.ReadFull(voiceNonceReader, nonce[:])
+
+		packet = secretbox.Seal(packet, payload, &nonce, &e.key)
+		return append(packet, nonce[:]...)
+	default:
+		copy(nonce[:], header)
+
+		return secretbox.Seal(packet, payload, &nonce, &e.key)
+	}
+}
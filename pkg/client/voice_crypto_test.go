@@ -0,0 +1,229 @@
+package client
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+var (
+	testVoiceHeader  = []byte{0x80, 0x78, 0x00, 0x01, 0x00, 0x00, 0x03, 0xc0, 0x01, 0x02, 0x03, 0x04}
+	testVoicePayload = []byte("opus frame")
+)
+
+func testVoiceKey() [32]byte {
+	var key [32]byte
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	return key
+}
+
+// fixedNonceReader returns the same byte as the random suffix nonce.
+type fixedNonceReader byte
+
+func (r fixedNonceReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+
+	return len(p), nil
+}
+
+func withNonceReader(t *testing.T, reader io.Reader) {
+	voiceNonceReader = reader
+	t.Cleanup(func() {
+		voiceNonceReader = rand.Reader
+	})
+}
+
+// TestVoiceEncrypterKnownAnswers checks the modes against published test vectors where their nonce layout allows: the
+// AES-256 GCM test case 14 of the GCM specification, which uses a zero nonce, i.e. the first rtpsize packet, and
+// the secretbox vector generated with the C implementation of NaCl, fed in as the random suffix nonce.
+func TestVoiceEncrypterKnownAnswers(t *testing.T) {
+	t.Run(aeadAES256GCMRTPSize, func(t *testing.T) {
+		encrypter, err := newVoiceEncrypter(aeadAES256GCMRTPSize, [32]byte{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		packet := encrypter.Seal(nil, make([]byte, 16))
+
+		want := "cea7403d4d606b6e074ec5d3baf39d18" + "d0d1c8a799996bf0265b98b5d48ab919" + "00000000"
+		if got := hex.EncodeToString(packet); got != want {
+			t.Fatalf("got packet %s, want %s", got, want)
+		}
+	})
+
+	// The vector of draft-irtf-cfrg-xchacha A.3.1 has a nonce the rtpsize layout cannot produce, so it checks the
+	// primitive TestVoiceEncrypterSeal builds the expected packets with.
+	t.Run(aeadXChaCha20Poly1305RTPSize, func(t *testing.T) {
+		key, _ := hex.DecodeString("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f")
+		nonce, _ := hex.DecodeString("404142434445464748494a4b4c4d4e4f5051525354555657")
+		additionalData, _ := hex.DecodeString("50515253c0c1c2c3c4c5c6c7")
+		plaintext := "Ladies and Gentlemen of the class of '99: If I could offer you only one tip for the future, " +
+			"sunscreen would be it."
+
+		aead, err := chacha20poly1305.NewX(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := "bd6d179d3e83d43b9576579493c0e939572a1700252bfaccbed2902c21396cbb731c7f1b0b4aa6440bf3a82f4eda7e39" +
+			"ae64c6708c54c216cb96b72e1213b4522f8c9ba40db5d945b11b69b982c1bb9e3f3fac2bc369488f76b2383565d3fff9" +
+			"21f9664c97637da9768812f615c68b13b52ec0875924c1c7987947deafd8780acf49"
+		if got := hex.EncodeToString(aead.Seal(nil, nonce, []byte(plaintext), additionalData)); got != want {
+			t.Fatalf("got ciphertext %s, want %s", got, want)
+		}
+	})
+
+	t.Run(xsalsa20Poly1305Suffix, func(t *testing.T) {
+		withNonceReader(t, fixedNonceReader(2))
+
+		var key [32]byte
+		for i := range key {
+			key[i] = 1
+		}
+
+		encrypter, err := newVoiceEncrypter(xsalsa20Poly1305Suffix, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		packet := encrypter.Seal(nil, bytes.Repeat([]byte{3}, 64))
+
+		want := "8442bc313f4626f1359e3b50122b6ce6fe66ddfe7d39d14e637eb4fd5b45beadab55198df6ab5368439792a23c87db70" +
+			"acb6156dc5ef957ac04f6276cf6093b84be77ff0849cc33e34b7254d5a8f65ad" + strings.Repeat("02", xsalsa20NonceSize)
+		if got := hex.EncodeToString(packet); got != want {
+			t.Fatalf("got packet %s, want %s", got, want)
+		}
+	})
+}
+
+// TestVoiceEncrypterSeal compares the packets with ones built from the layouts documented by Discord, using the
+// primitives directly with nonces written out by hand.
+func TestVoiceEncrypterSeal(t *testing.T) {
+	withNonceReader(t, fixedNonceReader(0xa5))
+
+	key := testVoiceKey()
+
+	gcmBlock, err := aes.NewCipher(key[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(gcmBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xchacha, err := chacha20poly1305.NewX(key[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The counter of the nonce is fixed at 7, it is the first four bytes of the nonce, big endian.
+	counter := []byte{0x00, 0x00, 0x00, 0x07}
+	counterNonce := func(size int) []byte {
+		return append(append([]byte{}, counter...), make([]byte, size-len(counter))...)
+	}
+	secretboxNonce := func(nonce []byte) *[xsalsa20NonceSize]byte {
+		var n [xsalsa20NonceSize]byte
+		copy(n[:], nonce)
+		return &n
+	}
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+
+	suffix := bytes.Repeat([]byte{0xa5}, xsalsa20NonceSize)
+
+	tests := []struct {
+		mode   string
+		packet []byte
+	}{
+		{
+			// The header is the additional data, the counter is appended.
+			mode:   aeadAES256GCMRTPSize,
+			packet: join(testVoiceHeader, gcm.Seal(nil, counterNonce(12), testVoicePayload, testVoiceHeader), counter),
+		},
+		{
+			mode:   aeadXChaCha20Poly1305RTPSize,
+			packet: join(testVoiceHeader, xchacha.Seal(nil, counterNonce(24), testVoicePayload, testVoiceHeader), counter),
+		},
+		{
+			// The nonce is the RTP header padded with zeros.
+			mode: xsalsa20Poly1305,
+			packet: join(testVoiceHeader,
+				secretbox.Seal(nil, testVoicePayload, secretboxNonce(testVoiceHeader), &key)),
+		},
+		{
+			mode: xsalsa20Poly1305Lite,
+			packet: join(testVoiceHeader,
+				secretbox.Seal(nil, testVoicePayload, secretboxNonce(counter), &key), counter),
+		},
+		{
+			// The random nonce is appended whole.
+			mode: xsalsa20Poly1305Suffix,
+			packet: join(testVoiceHeader,
+				secretbox.Seal(nil, testVoicePayload, secretboxNonce(suffix), &key), suffix),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.mode, func(t *testing.T) {
+			encrypter, err := newVoiceEncrypter(test.mode, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			switch e := encrypter.(type) {
+			case *rtpSizeEncrypter:
+				e.nonce = 7
+			case *xsalsa20Encrypter:
+				e.nonce = 7
+			}
+
+			packet := encrypter.Seal(testVoiceHeader, testVoicePayload)
+			if !bytes.Equal(packet, test.packet) {
+				t.Fatalf("got packet %x, want %x", packet, test.packet)
+			}
+		})
+	}
+}
+
+func TestVoiceEncrypterNonceIncrements(t *testing.T) {
+	encrypter, err := newVoiceEncrypter(aeadAES256GCMRTPSize, testVoiceKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := encrypter.Seal(testVoiceHeader, testVoicePayload)
+	second := encrypter.Seal(testVoiceHeader, testVoicePayload)
+
+	if !bytes.HasSuffix(first, []byte{0, 0, 0, 0}) || !bytes.HasSuffix(second, []byte{0, 0, 0, 1}) {
+		t.Fatalf("unexpected nonces %x and %x", first[len(first)-4:], second[len(second)-4:])
+	}
+}
+
+func TestSelectVoiceMode(t *testing.T) {
+	mode, err := selectVoiceMode([]string{xsalsa20Poly1305, aeadXChaCha20Poly1305RTPSize, "unknown"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode != aeadXChaCha20Poly1305RTPSize {
+		t.Fatalf("got mode %s, want %s", mode, aeadXChaCha20Poly1305RTPSize)
+	}
+
+	_, err = selectVoiceMode([]string{"unknown"})
+	if err == nil {
+		t.Fatal("expected an error for unsupported modes")
+	}
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/valyala/fastjson"
+	"nhooyr.io/websocket"
+)
+
+func TestVoiceHelloReplacesHeartbeat(t *testing.T) {
+	var heartbeats int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close(websocket.StatusNormalClosure, "")
+
+		for {
+			_, body, err := ws.Read(r.Context())
+			if err != nil {
+				return
+			}
+
+			if fastjson.GetInt(body, "op") == 3 {
+				atomic.AddInt32(&heartbeats, 1)
+			}
+		}
+	}))
+	defer server.Close()
+
+	c := NewVoiceClient(context.Background(), nil)
+	defer c.Close()
+
+	ws, _, err := websocket.Dial(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close(websocket.StatusNormalClosure, "")
+
+	// The first heartbeat would beat every 20 ms, the second Hello replaces it with one beating every hour.
+	err = c.handleHello(ws, []byte(`{"heartbeat_interval":20}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.handleHello(ws, []byte(`{"heartbeat_interval":3600000}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	// Each heartbeat beats once right away, the first one may have beaten once more before it was stopped.
+	if n := atomic.LoadInt32(&heartbeats); n < 2 || n > 3 {
+		t.Fatalf("got %d heartbeats, want the first heartbeat to stop after the second Hello", n)
+	}
+}
+
+func TestVoiceReadyAfterClose(t *testing.T) {
+	c := NewVoiceClient(context.Background(), nil)
+	c.Close()
+
+	err := c.handleReady(nil, []byte(`{"ssrc":1,"ip":"127.0.0.1","port":50000,"modes":["aead_aes256_gcm_rtpsize"]}`))
+	if err == nil {
+		t.Fatal("expected the ready of a closed voice client to fail")
+	}
+	if c.getTransport() != nil {
+		t.Fatal("expected the UDP connection of a closed voice client to be closed, not kept")
+	}
+}
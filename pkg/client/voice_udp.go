@@ -0,0 +1,119 @@
+package client
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	ipDiscoveryPacketSize = 74
+	ipDiscoveryRequest    = 0x1
+	ipDiscoveryResponse   = 0x2
+
+	rtpHeaderSize  = 12
+	rtpVersion     = 0x80
+	rtpPayloadType = 0x78
+
+	opusFrameDuration   = 20 * time.Millisecond
+	opusSamplesPerFrame = 960
+)
+
+var opusSilenceFrame = []byte{0xF8, 0xFF, 0xFE}
+
+// voiceUDPTransport carries RTP packets between the client and the voice server.
+type voiceUDPTransport struct {
+	conn      *net.UDPConn
+	ssrc      uint32
+	encrypter voiceEncrypter
+
+	sequence  uint16
+	timestamp uint32
+}
+
+func newVoiceUDPTransport(ip string, port int, ssrc uint32) (*voiceUDPTransport, error) {
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: net.ParseIP(ip), Port: port})
+	if err != nil {
+		return nil, err
+	}
+
+	return &voiceUDPTransport{
+		conn:      conn,
+		ssrc:      ssrc,
+		sequence:  uint16(rand.Uint32()),
+		timestamp: rand.Uint32(),
+	}, nil
+}
+
+// DiscoverIP asks the voice server for the external address and port of the UDP socket.
+func (t *voiceUDPTransport) DiscoverIP() (string, int, error) {
+	packet := make([]byte, ipDiscoveryPacketSize)
+	binary.BigEndian.PutUint16(packet[0:2], ipDiscoveryRequest)
+	binary.BigEndian.PutUint16(packet[2:4], ipDiscoveryPacketSize-4)
+	binary.BigEndian.PutUint32(packet[4:8], t.ssrc)
+
+	_, err := t.conn.Write(packet)
+	if err != nil {
+		return "", 0, err
+	}
+
+	err = t.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err != nil {
+		return "", 0, err
+	}
+	defer t.conn.SetReadDeadline(time.Time{})
+
+	n, err := t.conn.Read(packet)
+	if err != nil {
+		return "", 0, fmt.Errorf("could not receive ip discovery response: %w", err)
+	}
+
+	if n != ipDiscoveryPacketSize || binary.BigEndian.Uint16(packet[0:2]) != ipDiscoveryResponse {
+		return "", 0, fmt.Errorf("received malformed ip discovery response")
+	}
+
+	address := strings.TrimRight(string(packet[8:72]), "\x00")
+	port := int(binary.BigEndian.Uint16(packet[72:74]))
+
+	return address, port, nil
+}
+
+func (t *voiceUDPTransport) SetEncrypter(encrypter voiceEncrypter) {
+	t.encrypter = encrypter
+}
+
+// WriteFrame wraps a single Opus packet in an encrypted RTP packet and sends it, samples is the number of 48 kHz
+// samples in the packet and advances the RTP timestamp.
+func (t *voiceUDPTransport) WriteFrame(frame []byte, samples uint32) error {
+	if t.encrypter == nil {
+		return fmt.Errorf("voice session description has not been received yet")
+	}
+
+	header := make([]byte, rtpHeaderSize)
+	t.putHeader(header)
+
+	_, err := t.conn.Write(t.encrypter.Seal(header, frame))
+	if err != nil {
+		return err
+	}
+
+	t.sequence++
+	t.timestamp += samples
+
+	return nil
+}
+
+func (t *voiceUDPTransport) putHeader(header []byte) {
+	header[0] = rtpVersion
+	header[1] = rtpPayloadType
+	binary.BigEndian.PutUint16(header[2:4], t.sequence)
+	binary.BigEndian.PutUint32(header[4:8], t.timestamp)
+	binary.BigEndian.PutUint32(header[8:12], t.ssrc)
+}
+
+func (t *voiceUDPTransport) Close() error {
+	return t.conn.Close()
+}
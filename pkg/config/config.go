@@ -10,12 +10,20 @@ import (
 const configFileName = "config.yaml"
 
 type Config struct {
-	ServerID     string `yaml:"server-id"`
-	Token        string `yaml:"token"`
-	ClientID     string `yaml:"client-id"`
-	Permissions  string `yaml:"permissions"`
-	ClientSecret string `yaml:"client-secret"`
-	RedirectURL  string `yaml:"redirect-url"`
+	ServerID            string   `yaml:"server-id"`
+	Token               string   `yaml:"token"`
+	ClientID            string   `yaml:"client-id"`
+	Permissions         string   `yaml:"permissions"`
+	ClientSecret        string   `yaml:"client-secret"`
+	RedirectURL         string   `yaml:"redirect-url"`
+	Cache               []string `yaml:"cache"`
+	APIURL              string   `yaml:"api-url"`
+	PublicKey           string   `yaml:"public-key"`
+	InteractionsAddress string   `yaml:"interactions-address"`
+	MusicDir            string   `yaml:"music-dir"`
+	Shards              int      `yaml:"shards"`
+	Compress            string   `yaml:"compress"`
+	Encoding            string   `yaml:"encoding"`
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -0,0 +1,64 @@
+package etf
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bsponge/discordGopher/pkg/object"
+
+	"github.com/valyala/fastjson"
+)
+
+// BenchmarkUnmarshal compares decoding the same dispatches received with both gateway encodings. fastjson only
+// parses the payload, which is what the gateway read loop does with JSON before the payload is unmarshaled.
+func BenchmarkUnmarshal(b *testing.B) {
+	fixtures := []struct {
+		dispatch object.Dispatch
+		new      func() any
+	}{
+		{dispatch: object.ReadyType, new: func() any { return &object.Ready{} }},
+		{dispatch: object.MessageCreateType, new: func() any { return &object.Message{} }},
+		{dispatch: object.GuildCreateType, new: func() any { return &object.Guild{} }},
+	}
+
+	for _, fixture := range fixtures {
+		data, term := readFixture(b, fixture.dispatch)
+
+		b.Run(string(fixture.dispatch)+"/etf", func(b *testing.B) {
+			b.SetBytes(int64(len(term)))
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				err := Unmarshal(term, fixture.new())
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(string(fixture.dispatch)+"/encoding-json", func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				err := json.Unmarshal(data, fixture.new())
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(string(fixture.dispatch)+"/fastjson", func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+
+			var p fastjson.Parser
+			for i := 0; i < b.N; i++ {
+				_, err := p.ParseBytes(data)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,790 @@
+package etf
+
+import (
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+var (
+	rawTermType         = reflect.TypeOf(RawTerm(nil))
+	rawMessageType      = reflect.TypeOf(json.RawMessage(nil))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+	errUnexpectedEnd = errors.New("etf: unexpected end of term")
+
+	// maxExactFloat is the biggest integer a float64 holds without rounding.
+	maxExactFloat = big.NewInt(1 << 53)
+)
+
+// Unmarshal decodes the term into the value pointed to by v. Fields are matched by their json tags, so the types
+// used with encoding/json, like the ones from pkg/object, can be used as they are. As Discord sends snowflakes as
+// integers, integers are converted into strings when the field is a string. The atoms nil, true and false are
+// decoded into nil and booleans, the other atoms into strings.
+func Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("etf: Unmarshal needs a non-nil pointer, got %T", v)
+	}
+
+	d := decodeState{data: data}
+
+	version, err := d.byte()
+	if err != nil {
+		return err
+	}
+	if version != versionTag {
+		return fmt.Errorf("etf: unsupported version %d", version)
+	}
+
+	err = d.value(rv.Elem())
+	if err != nil {
+		return err
+	}
+
+	if d.off != len(d.data) {
+		return fmt.Errorf("etf: %d bytes left after the term", len(d.data)-d.off)
+	}
+
+	return nil
+}
+
+type decodeState struct {
+	data []byte
+	off  int
+}
+
+func (d *decodeState) byte() (byte, error) {
+	if d.off >= len(d.data) {
+		return 0, errUnexpectedEnd
+	}
+
+	b := d.data[d.off]
+	d.off++
+
+	return b, nil
+}
+
+func (d *decodeState) bytes(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.off < n {
+		return nil, errUnexpectedEnd
+	}
+
+	b := d.data[d.off : d.off+n]
+	d.off += n
+
+	return b, nil
+}
+
+func (d *decodeState) uint16() (int, error) {
+	b, err := d.bytes(2)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(binary.BigEndian.Uint16(b)), nil
+}
+
+func (d *decodeState) uint32() (int, error) {
+	b, err := d.bytes(4)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(binary.BigEndian.Uint32(b)), nil
+}
+
+// checkCount makes sure that the input holds n terms of at least size bytes each, so that a corrupt length does not
+// allocate more than the input could ever fill.
+func (d *decodeState) checkCount(n int, size int) error {
+	if n < 0 || n > (len(d.data)-d.off)/size {
+		return fmt.Errorf("etf: length %d exceeds the remaining %d bytes", n, len(d.data)-d.off)
+	}
+
+	return nil
+}
+
+func (d *decodeState) peek() (byte, error) {
+	if d.off >= len(d.data) {
+		return 0, errUnexpectedEnd
+	}
+
+	return d.data[d.off], nil
+}
+
+func (d *decodeState) value(v reflect.Value) error {
+	switch v.Type() {
+	case rawTermType:
+		start := d.off
+		err := d.skip()
+		if err != nil {
+			return err
+		}
+
+		raw := make(RawTerm, 0, d.off-start+1)
+		raw = append(raw, versionTag)
+		v.SetBytes(append(raw, d.data[start:d.off]...))
+
+		return nil
+	case rawMessageType:
+		// Fields kept raw for encoding/json, e.g. option values, are converted into JSON.
+		value, err := d.any()
+		if err != nil {
+			return err
+		}
+
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+
+		v.SetBytes(raw)
+
+		return nil
+	}
+
+	tag, err := d.peek()
+	if err != nil {
+		return err
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if isAtomTag(tag) && d.nextIsNil() {
+			d.skip()
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		return d.value(v.Elem())
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return fmt.Errorf("etf: cannot unmarshal into non-empty interface %s", v.Type())
+		}
+
+		value, err := d.any()
+		if err != nil {
+			return err
+		}
+
+		if value == nil {
+			v.Set(reflect.Zero(v.Type()))
+		} else {
+			v.Set(reflect.ValueOf(value))
+		}
+
+		return nil
+	}
+
+	d.off++
+
+	switch tag {
+	case smallIntegerTag, integerTag, smallBigTag, largeBigTag:
+		i, err := d.integer(tag)
+		if err != nil {
+			return err
+		}
+		return setInteger(v, i)
+	case newFloatTag, floatTag:
+		f, err := d.float(tag)
+		if err != nil {
+			return err
+		}
+		return setFloat(v, f)
+	case atomTag, smallAtomTag, atomUTF8Tag, smallAtomUTF8Tag:
+		atom, err := d.atom(tag)
+		if err != nil {
+			return err
+		}
+		return setAtom(v, atom)
+	case binaryTag, bitBinaryTag:
+		b, err := d.binary(tag)
+		if err != nil {
+			return err
+		}
+		return setBinary(v, b)
+	case stringTag:
+		return d.string(v)
+	case nilTag:
+		return setEmptyList(v)
+	case listTag:
+		n, err := d.uint32()
+		if err != nil {
+			return err
+		}
+		err = d.list(v, n)
+		if err != nil {
+			return err
+		}
+		return d.listTail()
+	case smallTupleTag, largeTupleTag:
+		n, err := d.arity(tag)
+		if err != nil {
+			return err
+		}
+		return d.list(v, n)
+	case mapTag:
+		n, err := d.uint32()
+		if err != nil {
+			return err
+		}
+		return d.mapping(v, n)
+	case compressedTermTag:
+		return errors.New("etf: compressed terms are not supported")
+	default:
+		return fmt.Errorf("etf: unknown tag %d", tag)
+	}
+}
+
+func isAtomTag(tag byte) bool {
+	return tag == atomTag || tag == smallAtomTag || tag == atomUTF8Tag || tag == smallAtomUTF8Tag
+}
+
+// nextIsNil reports whether the next term is the nil atom, which Discord sends for JSON nulls.
+func (d *decodeState) nextIsNil() bool {
+	saved := d.off
+	defer func() {
+		d.off = saved
+	}()
+
+	tag, err := d.byte()
+	if err != nil || !isAtomTag(tag) {
+		return false
+	}
+
+	atom, err := d.atom(tag)
+
+	return err == nil && atom == "nil"
+}
+
+func (d *decodeState) integer(tag byte) (*big.Int, error) {
+	switch tag {
+	case smallIntegerTag:
+		b, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		return big.NewInt(int64(b)), nil
+	case integerTag:
+		b, err := d.bytes(4)
+		if err != nil {
+			return nil, err
+		}
+		return big.NewInt(int64(int32(binary.BigEndian.Uint32(b)))), nil
+	}
+
+	var n int
+	var err error
+	if tag == smallBigTag {
+		var b byte
+		b, err = d.byte()
+		n = int(b)
+	} else {
+		n, err = d.uint32()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sign, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+
+	digits, err := d.bytes(n)
+	if err != nil {
+		return nil, err
+	}
+
+	// The digits are little-endian, big.Int expects them big-endian.
+	reversed := make([]byte, n)
+	for i, digit := range digits {
+		reversed[n-1-i] = digit
+	}
+
+	i := new(big.Int).SetBytes(reversed)
+	if sign != 0 {
+		i.Neg(i)
+	}
+
+	return i, nil
+}
+
+func (d *decodeState) float(tag byte) (float64, error) {
+	if tag == newFloatTag {
+		b, err := d.bytes(8)
+		if err != nil {
+			return 0, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	}
+
+	// FLOAT_EXT is the float formatted as text and padded with zeros.
+	b, err := d.bytes(31)
+	if err != nil {
+		return 0, err
+	}
+
+	end := 0
+	for end < len(b) && b[end] != 0 {
+		end++
+	}
+
+	return strconv.ParseFloat(string(b[:end]), 64)
+}
+
+func (d *decodeState) atom(tag byte) (string, error) {
+	var n int
+	var err error
+	if tag == smallAtomTag || tag == smallAtomUTF8Tag {
+		var b byte
+		b, err = d.byte()
+		n = int(b)
+	} else {
+		n, err = d.uint16()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	b, err := d.bytes(n)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+func (d *decodeState) binary(tag byte) ([]byte, error) {
+	n, err := d.uint32()
+	if err != nil {
+		return nil, err
+	}
+
+	if tag == bitBinaryTag {
+		// The number of bits used in the last byte does not matter for the strings Discord sends.
+		_, err = d.byte()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return d.bytes(n)
+}
+
+func (d *decodeState) arity(tag byte) (int, error) {
+	if tag == smallTupleTag {
+		b, err := d.byte()
+		return int(b), err
+	}
+
+	return d.uint32()
+}
+
+// string decodes STRING_EXT, which Erlang uses for lists of small integers. It is decoded into strings and byte
+// slices as it is and into other slices element by element.
+func (d *decodeState) string(v reflect.Value) error {
+	n, err := d.uint16()
+	if err != nil {
+		return err
+	}
+
+	b, err := d.bytes(n)
+	if err != nil {
+		return err
+	}
+
+	if v.Kind() == reflect.String || (v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8) {
+		return setBinary(v, b)
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+	case reflect.Array:
+	default:
+		return typeError("list", v)
+	}
+
+	for i, c := range b {
+		if i >= v.Len() {
+			break
+		}
+		err := setInteger(v.Index(i), big.NewInt(int64(c)))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *decodeState) list(v reflect.Value, n int) error {
+	err := d.checkCount(n, 1)
+	if err != nil {
+		return err
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+	case reflect.Array:
+	default:
+		return typeError("list", v)
+	}
+
+	for i := 0; i < n; i++ {
+		var err error
+		if i < v.Len() {
+			err = d.value(v.Index(i))
+		} else {
+			err = d.skip()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *decodeState) listTail() error {
+	tag, err := d.byte()
+	if err != nil {
+		return err
+	}
+
+	if tag != nilTag {
+		return errors.New("etf: improper lists are not supported")
+	}
+
+	return nil
+}
+
+func (d *decodeState) mapping(v reflect.Value, n int) error {
+	// Both the key and the value take at least one byte.
+	err := d.checkCount(n, 2)
+	if err != nil {
+		return err
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		fields := fields(v.Type())
+		for i := 0; i < n; i++ {
+			key, err := d.key()
+			if err != nil {
+				return err
+			}
+
+			f, ok := fields.byName[key]
+			if !ok {
+				err = d.skip()
+			} else {
+				err = d.value(fieldByIndex(v, f.index))
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case reflect.Map:
+		keyType := v.Type().Key()
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), n))
+		}
+
+		for i := 0; i < n; i++ {
+			key, err := d.key()
+			if err != nil {
+				return err
+			}
+
+			keyValue := reflect.New(keyType).Elem()
+			switch keyType.Kind() {
+			case reflect.String:
+				keyValue.SetString(key)
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				i, err := strconv.ParseInt(key, 10, 64)
+				if err != nil {
+					return fmt.Errorf("etf: invalid map key %q for %s", key, v.Type())
+				}
+				keyValue.SetInt(i)
+			default:
+				return fmt.Errorf("etf: unsupported map key type %s", keyType)
+			}
+
+			elem := reflect.New(v.Type().Elem()).Elem()
+			err = d.value(elem)
+			if err != nil {
+				return err
+			}
+
+			v.SetMapIndex(keyValue, elem)
+		}
+
+		return nil
+	default:
+		return typeError("map", v)
+	}
+}
+
+// key decodes a map key, Discord uses atoms for field names and binaries or integers for IDs.
+func (d *decodeState) key() (string, error) {
+	tag, err := d.peek()
+	if err != nil {
+		return "", err
+	}
+
+	switch tag {
+	case atomTag, smallAtomTag, atomUTF8Tag, smallAtomUTF8Tag:
+		d.off++
+		return d.atom(tag)
+	case binaryTag, bitBinaryTag:
+		d.off++
+		b, err := d.binary(tag)
+		return string(b), err
+	}
+
+	var key any
+	err = d.value(reflect.ValueOf(&key).Elem())
+	if err != nil {
+		return "", err
+	}
+
+	switch key := key.(type) {
+	case string:
+		return key, nil
+	case float64:
+		return strconv.FormatFloat(key, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("etf: unsupported map key %v", key)
+	}
+}
+
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v
+}
+
+// any decodes the next term the way encoding/json decodes into an empty interface: numbers become float64, lists
+// []any and maps map[string]any. Integers too big for a float64, i.e. snowflakes, become strings as in JSON.
+func (d *decodeState) any() (any, error) {
+	tag, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tag {
+	case smallIntegerTag, integerTag, smallBigTag, largeBigTag:
+		i, err := d.integer(tag)
+		if err != nil {
+			return nil, err
+		}
+		if new(big.Int).Abs(i).Cmp(maxExactFloat) > 0 {
+			return i.String(), nil
+		}
+		return float64(i.Int64()), nil
+	case newFloatTag, floatTag:
+		return d.float(tag)
+	case atomTag, smallAtomTag, atomUTF8Tag, smallAtomUTF8Tag:
+		atom, err := d.atom(tag)
+		if err != nil {
+			return nil, err
+		}
+		switch atom {
+		case "nil":
+			return nil, nil
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return atom, nil
+	case binaryTag, bitBinaryTag:
+		b, err := d.binary(tag)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case stringTag, nilTag, listTag, smallTupleTag, largeTupleTag:
+		d.off--
+		var list []any
+		err := d.value(reflect.ValueOf(&list).Elem())
+		return list, err
+	case mapTag:
+		d.off--
+		var m map[string]any
+		err := d.value(reflect.ValueOf(&m).Elem())
+		return m, err
+	default:
+		return nil, fmt.Errorf("etf: unknown tag %d", tag)
+	}
+}
+
+// skip moves past the next term.
+func (d *decodeState) skip() error {
+	tag, err := d.byte()
+	if err != nil {
+		return err
+	}
+
+	switch tag {
+	case smallIntegerTag:
+		_, err = d.bytes(1)
+	case integerTag:
+		_, err = d.bytes(4)
+	case newFloatTag:
+		_, err = d.bytes(8)
+	case floatTag:
+		_, err = d.bytes(31)
+	case smallBigTag, largeBigTag:
+		_, err = d.integer(tag)
+	case atomTag, smallAtomTag, atomUTF8Tag, smallAtomUTF8Tag:
+		_, err = d.atom(tag)
+	case binaryTag, bitBinaryTag:
+		_, err = d.binary(tag)
+	case stringTag:
+		var n int
+		n, err = d.uint16()
+		if err == nil {
+			_, err = d.bytes(n)
+		}
+	case nilTag:
+	case listTag:
+		var n int
+		n, err = d.uint32()
+		// The tail is skipped as one more element.
+		for i := 0; i <= n && err == nil; i++ {
+			err = d.skip()
+		}
+	case smallTupleTag, largeTupleTag:
+		var n int
+		n, err = d.arity(tag)
+		for i := 0; i < n && err == nil; i++ {
+			err = d.skip()
+		}
+	case mapTag:
+		var n int
+		n, err = d.uint32()
+		for i := 0; i < 2*n && err == nil; i++ {
+			err = d.skip()
+		}
+	default:
+		err = fmt.Errorf("etf: unknown tag %d", tag)
+	}
+
+	return err
+}
+
+func setInteger(v reflect.Value, i *big.Int) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !i.IsInt64() || v.OverflowInt(i.Int64()) {
+			return fmt.Errorf("etf: integer %s overflows %s", i, v.Type())
+		}
+		v.SetInt(i.Int64())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if !i.IsUint64() || v.OverflowUint(i.Uint64()) {
+			return fmt.Errorf("etf: integer %s overflows %s", i, v.Type())
+		}
+		v.SetUint(i.Uint64())
+	case reflect.Float32, reflect.Float64:
+		f, _ := new(big.Float).SetInt(i).Float64()
+		v.SetFloat(f)
+	case reflect.String:
+		v.SetString(i.String())
+	default:
+		return typeError("integer", v)
+	}
+
+	return nil
+}
+
+func setFloat(v reflect.Value, f float64) error {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(f)
+	default:
+		return typeError("float", v)
+	}
+
+	return nil
+}
+
+func setAtom(v reflect.Value, atom string) error {
+	switch atom {
+	case "nil":
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	case "true", "false":
+		if v.Kind() == reflect.Bool {
+			v.SetBool(atom == "true")
+			return nil
+		}
+	}
+
+	if v.Kind() != reflect.String {
+		return typeError("atom "+atom, v)
+	}
+
+	v.SetString(atom)
+
+	return nil
+}
+
+func setBinary(v reflect.Value, b []byte) error {
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText(b)
+	}
+
+	switch {
+	case v.Kind() == reflect.String:
+		v.SetString(string(b))
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		v.SetBytes(append([]byte(nil), b...))
+	default:
+		return typeError("binary", v)
+	}
+
+	return nil
+}
+
+func setEmptyList(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+	case reflect.Array:
+	case reflect.String:
+		// Erlang strings are lists, so the empty string is the empty list.
+		v.SetString("")
+	default:
+		return typeError("list", v)
+	}
+
+	return nil
+}
+
+func typeError(term string, v reflect.Value) error {
+	return fmt.Errorf("etf: cannot unmarshal %s into Go value of type %s", term, v.Type())
+}
@@ -0,0 +1,328 @@
+package etf
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+var (
+	jsonNumberType    = reflect.TypeOf(json.Number(""))
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Marshal encodes v with the same field names and omitempty rules as encoding/json. Struct fields are encoded as
+// atom keys, strings as binaries and nil values as the nil atom.
+func Marshal(v any) ([]byte, error) {
+	e := encodeState{buf: []byte{versionTag}}
+
+	err := e.value(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+
+	return e.buf, nil
+}
+
+type encodeState struct {
+	buf []byte
+}
+
+func (e *encodeState) value(v reflect.Value) error {
+	if !v.IsValid() {
+		e.atom("nil")
+		return nil
+	}
+
+	switch v.Type() {
+	case rawTermType:
+		if v.Len() == 0 {
+			e.atom("nil")
+			return nil
+		}
+		raw := v.Bytes()
+		if raw[0] != versionTag {
+			return fmt.Errorf("etf: raw term without version byte")
+		}
+		e.buf = append(e.buf, raw[1:]...)
+		return nil
+	case rawMessageType:
+		return e.json(v.Bytes())
+	case jsonNumberType:
+		return e.number(json.Number(v.String()))
+	}
+
+	if v.Kind() != reflect.Pointer && v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		e.binary(text)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.atom("true")
+		} else {
+			e.atom("false")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.integer(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			e.big(new(big.Int).SetUint64(v.Uint()))
+		} else {
+			e.integer(int64(v.Uint()))
+		}
+	case reflect.Float32, reflect.Float64:
+		e.float(v.Float())
+	case reflect.String:
+		e.binary([]byte(v.String()))
+	case reflect.Slice:
+		if v.IsNil() {
+			e.atom("nil")
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.binary(v.Bytes())
+			return nil
+		}
+		return e.list(v)
+	case reflect.Array:
+		return e.list(v)
+	case reflect.Map:
+		if v.IsNil() {
+			e.atom("nil")
+			return nil
+		}
+		return e.mapping(v)
+	case reflect.Struct:
+		return e.structure(v)
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			e.atom("nil")
+			return nil
+		}
+		return e.value(v.Elem())
+	default:
+		return fmt.Errorf("etf: unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+func (e *encodeState) atom(atom string) {
+	if len(atom) < 256 {
+		e.buf = append(e.buf, smallAtomUTF8Tag, byte(len(atom)))
+	} else {
+		e.buf = append(e.buf, atomUTF8Tag)
+		e.buf = appendUint16(e.buf, uint16(len(atom)))
+	}
+
+	e.buf = append(e.buf, atom...)
+}
+
+func (e *encodeState) integer(i int64) {
+	switch {
+	case i >= 0 && i <= math.MaxUint8:
+		e.buf = append(e.buf, smallIntegerTag, byte(i))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		e.buf = append(e.buf, integerTag)
+		e.buf = appendUint32(e.buf, uint32(int32(i)))
+	default:
+		e.big(big.NewInt(i))
+	}
+}
+
+func (e *encodeState) big(i *big.Int) {
+	digits := new(big.Int).Abs(i).Bytes()
+
+	var sign byte
+	if i.Sign() < 0 {
+		sign = 1
+	}
+
+	e.buf = append(e.buf, smallBigTag, byte(len(digits)), sign)
+
+	// big.Int returns the digits big-endian, ETF stores them little-endian.
+	for j := len(digits) - 1; j >= 0; j-- {
+		e.buf = append(e.buf, digits[j])
+	}
+}
+
+func (e *encodeState) float(f float64) {
+	e.buf = append(e.buf, newFloatTag)
+	e.buf = appendUint64(e.buf, math.Float64bits(f))
+}
+
+func (e *encodeState) binary(b []byte) {
+	e.buf = append(e.buf, binaryTag)
+	e.buf = appendUint32(e.buf, uint32(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encodeState) number(n json.Number) error {
+	i, err := strconv.ParseInt(string(n), 10, 64)
+	if err == nil {
+		e.integer(i)
+		return nil
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return fmt.Errorf("etf: invalid number %q: %w", n, err)
+	}
+
+	e.float(f)
+
+	return nil
+}
+
+// json encodes a JSON value, numbers keep their exact value.
+func (e *encodeState) json(raw []byte) error {
+	if len(raw) == 0 {
+		e.atom("nil")
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var value any
+	err := decoder.Decode(&value)
+	if err != nil {
+		return err
+	}
+
+	return e.value(reflect.ValueOf(value))
+}
+
+func (e *encodeState) list(v reflect.Value) error {
+	if v.Len() == 0 {
+		e.buf = append(e.buf, nilTag)
+		return nil
+	}
+
+	e.buf = append(e.buf, listTag)
+	e.buf = appendUint32(e.buf, uint32(v.Len()))
+
+	for i := 0; i < v.Len(); i++ {
+		err := e.value(v.Index(i))
+		if err != nil {
+			return err
+		}
+	}
+
+	e.buf = append(e.buf, nilTag)
+
+	return nil
+}
+
+func (e *encodeState) mapping(v reflect.Value) error {
+	e.buf = append(e.buf, mapTag)
+	e.buf = appendUint32(e.buf, uint32(v.Len()))
+
+	iter := v.MapRange()
+	for iter.Next() {
+		key := iter.Key()
+		switch key.Kind() {
+		case reflect.String:
+			e.binary([]byte(key.String()))
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			e.binary([]byte(strconv.FormatInt(key.Int(), 10)))
+		default:
+			return fmt.Errorf("etf: unsupported map key type %s", key.Type())
+		}
+
+		err := e.value(iter.Value())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *encodeState) structure(v reflect.Value) error {
+	type encodedField struct {
+		name  string
+		value reflect.Value
+	}
+
+	var encoded []encodedField
+	for _, f := range fields(v.Type()).list {
+		value, ok := fieldValue(v, f.index)
+		if !ok || (f.omitEmpty && isEmpty(value)) {
+			continue
+		}
+
+		encoded = append(encoded, encodedField{name: f.name, value: value})
+	}
+
+	e.buf = append(e.buf, mapTag)
+	e.buf = appendUint32(e.buf, uint32(len(encoded)))
+
+	for _, f := range encoded {
+		e.atom(f.name)
+
+		err := e.value(f.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fieldValue returns the field, false if it is promoted from a nil embedded pointer.
+func fieldValue(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v, true
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+
+	return false
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(v>>32)), uint32(v))
+}
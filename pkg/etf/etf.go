@@ -0,0 +1,118 @@
+package etf
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Tags of the Erlang External Term Format, see https://www.erlang.org/doc/apps/erts/erl_ext_dist.html.
+const (
+	versionTag = 131
+
+	newFloatTag       = 70
+	bitBinaryTag      = 77
+	smallIntegerTag   = 97
+	integerTag        = 98
+	floatTag          = 99
+	atomTag           = 100
+	smallTupleTag     = 104
+	largeTupleTag     = 105
+	nilTag            = 106
+	stringTag         = 107
+	listTag           = 108
+	binaryTag         = 109
+	smallBigTag       = 110
+	largeBigTag       = 111
+	smallAtomTag      = 115
+	mapTag            = 116
+	atomUTF8Tag       = 118
+	smallAtomUTF8Tag  = 119
+	compressedTermTag = 80
+)
+
+// RawTerm is an encoded term, including the version byte. It can be used to delay decoding, like json.RawMessage.
+type RawTerm []byte
+
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+type structFields struct {
+	list   []field
+	byName map[string]field
+}
+
+var fieldCache sync.Map // map[reflect.Type]*structFields
+
+// fields returns the fields of the struct type named after their json tags, the way encoding/json sees them.
+// Fields of embedded structs without a tag are promoted unless the outer struct has a field with the same name.
+func fields(t reflect.Type) *structFields {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.(*structFields)
+	}
+
+	var result []field
+	var embedded []field
+	names := make(map[string]bool)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+
+		fieldType := f.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		if f.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			for _, promoted := range fields(fieldType).list {
+				promoted.index = append([]int{i}, promoted.index...)
+				embedded = append(embedded, promoted)
+			}
+			continue
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		names[name] = true
+		result = append(result, field{
+			name:      name,
+			index:     []int{i},
+			omitEmpty: strings.Contains(options, "omitempty"),
+		})
+	}
+
+	for _, f := range embedded {
+		if !names[f.name] {
+			names[f.name] = true
+			result = append(result, f)
+		}
+	}
+
+	cached := &structFields{
+		list:   result,
+		byName: make(map[string]field, len(result)),
+	}
+	for _, f := range result {
+		cached.byName[f.name] = f
+	}
+
+	fieldCache.Store(t, cached)
+
+	return cached
+}
@@ -0,0 +1,357 @@
+package etf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/bsponge/discordGopher/pkg/object"
+)
+
+var snowflakeRegex = regexp.MustCompile(`^\d{15,}$`)
+
+// readFixture returns the JSON payload of the dispatch captured in testdata together with the same payload encoded
+// the way Discord does with ETF, i.e. with the snowflakes as integers.
+func readFixture(tb testing.TB, dispatch object.Dispatch) ([]byte, []byte) {
+	tb.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", string(dispatch)+".json"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	err = decoder.Decode(&value)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	term, err := Marshal(snowflakesToIntegers(value))
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return data, term
+}
+
+func snowflakesToIntegers(value any) any {
+	switch value := value.(type) {
+	case string:
+		if snowflakeRegex.MatchString(value) {
+			return json.Number(value)
+		}
+	case []any:
+		for i, v := range value {
+			value[i] = snowflakesToIntegers(v)
+		}
+	case map[string]any:
+		for k, v := range value {
+			value[k] = snowflakesToIntegers(v)
+		}
+	}
+
+	return value
+}
+
+func TestUnmarshalFixtures(t *testing.T) {
+	tests := []struct {
+		dispatch object.Dispatch
+		new      func() any
+	}{
+		{dispatch: object.ReadyType, new: func() any { return &object.Ready{} }},
+		{dispatch: object.MessageCreateType, new: func() any { return &object.Message{} }},
+		{dispatch: object.GuildCreateType, new: func() any { return &object.Guild{} }},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.dispatch), func(t *testing.T) {
+			data, term := readFixture(t, test.dispatch)
+
+			want := test.new()
+			err := json.Unmarshal(data, want)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := test.new()
+			err = Unmarshal(term, got)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("ETF and JSON decode differently:\n%+v\n%+v", got, want)
+			}
+		})
+	}
+}
+
+type testSnowflakes struct {
+	Small   string  `json:"small"`
+	Large   string  `json:"large"`
+	Pointer *string `json:"pointer"`
+	Number  uint64  `json:"number"`
+}
+
+func TestUnmarshalBigIntegers(t *testing.T) {
+	var term []byte
+	term = append(term, versionTag, mapTag, 0, 0, 0, 4)
+
+	// 1149071652235489301 as SMALL_BIG_EXT.
+	term = append(term, smallAtomUTF8Tag, 5)
+	term = append(term, "small"...)
+	term = append(term, smallBigTag, 8, 0, 0x15, 0xb0, 0xf7, 0x64, 0x94, 0x52, 0xf2, 0x0f)
+
+	// The same snowflake as LARGE_BIG_EXT, with leading zero digits.
+	term = append(term, smallAtomUTF8Tag, 5)
+	term = append(term, "large"...)
+	term = append(term, largeBigTag, 0, 0, 0, 10, 0, 0x15, 0xb0, 0xf7, 0x64, 0x94, 0x52, 0xf2, 0x0f, 0, 0)
+
+	term = append(term, smallAtomUTF8Tag, 7)
+	term = append(term, "pointer"...)
+	term = append(term, smallBigTag, 8, 0, 0x15, 0xb0, 0xf7, 0x64, 0x94, 0x52, 0xf2, 0x0f)
+
+	term = append(term, smallAtomUTF8Tag, 6)
+	term = append(term, "number"...)
+	term = append(term, smallBigTag, 8, 0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+
+	var got testSnowflakes
+	err := Unmarshal(term, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "1149071652235489301"
+	if got.Small != want || got.Large != want || got.Pointer == nil || *got.Pointer != want {
+		t.Fatalf("got %+v, want the snowflakes as %s", got, want)
+	}
+	if got.Number != 1<<64-1 {
+		t.Fatalf("got number %d, want %d", got.Number, uint64(1<<64-1))
+	}
+}
+
+type testNullable struct {
+	Name     *string          `json:"name"`
+	Count    *int             `json:"count"`
+	Tags     []string         `json:"tags"`
+	Value    json.RawMessage  `json:"value"`
+	Raw      RawTerm          `json:"raw"`
+	Children *[]testNullable  `json:"children,omitempty"`
+	Extra    map[string]int64 `json:"extra,omitempty"`
+}
+
+func TestUnmarshalNilAtom(t *testing.T) {
+	term, err := Marshal(map[string]any{"name": nil, "count": nil, "tags": nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(term, []byte{smallAtomUTF8Tag, 3, 'n', 'i', 'l'}) {
+		t.Fatalf("expected nil values to be encoded as the nil atom, got %x", term)
+	}
+
+	name, count := "stale", 3
+	got := testNullable{Name: &name, Count: &count, Tags: []string{"stale"}}
+	err = Unmarshal(term, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Name != nil || got.Count != nil || got.Tags != nil {
+		t.Fatalf("expected the nil atom to reset the fields, got %+v", got)
+	}
+}
+
+func TestUnmarshalRawMessage(t *testing.T) {
+	term, err := Marshal(map[string]any{
+		"value": map[string]any{
+			"id":      json.Number("1149071652235489301"),
+			"count":   json.Number("3"),
+			"ratio":   0.5,
+			"enabled": true,
+			"missing": nil,
+			"options": []any{"a", json.Number("1")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got testNullable
+	err = Unmarshal(term, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Integers which do not fit a float64 keep their value as strings, like snowflakes in JSON.
+	want := `{"count":3,"enabled":true,"id":"1149071652235489301","missing":null,"options":["a",1],"ratio":0.5}`
+	if string(got.Value) != want {
+		t.Fatalf("got %s, want %s", got.Value, want)
+	}
+}
+
+func TestRawTerm(t *testing.T) {
+	inner := map[string]any{"id": json.Number("1149071652235489301"), "name": "gopher"}
+
+	term, err := Marshal(map[string]any{"raw": inner, "count": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got testNullable
+	err = Unmarshal(term, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Raw) == 0 || got.Raw[0] != versionTag {
+		t.Fatalf("expected the raw term to start with the version byte, got %x", got.Raw)
+	}
+
+	var decoded struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	err = Unmarshal(got.Raw, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ID != "1149071652235489301" || decoded.Name != "gopher" {
+		t.Fatalf("got %+v from the raw term", decoded)
+	}
+
+	// A raw term is encoded again as it is.
+	reencoded, err := Marshal(struct {
+		Raw RawTerm `json:"raw"`
+	}{Raw: got.Raw})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var again testNullable
+	err = Unmarshal(reencoded, &again)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.Raw, got.Raw) {
+		t.Fatalf("got raw term %x after encoding it again, want %x", again.Raw, got.Raw)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	raw, err := Marshal([]any{"raw", json.Number("1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "gopher"
+	// Like encoding/json, a null is kept as it is in raw fields.
+	children := []testNullable{{Tags: []string{}, Value: json.RawMessage(`null`), Raw: raw}}
+	want := testNullable{
+		Raw:      raw,
+		Name:     &name,
+		Tags:     []string{"a", "b"},
+		Value:    json.RawMessage(`{"a":[1,2,"3"]}`),
+		Children: &children,
+		Extra:    map[string]int64{"big": -1 << 62, "small": 7},
+	}
+
+	term, err := Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got testNullable
+	err = Unmarshal(term, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestUnmarshalCorruptLengths(t *testing.T) {
+	terms := map[string][]byte{
+		"list":        {versionTag, listTag, 0xff, 0xff, 0xff, 0xff, nilTag},
+		"map":         {versionTag, mapTag, 0x7f, 0xff, 0xff, 0xff, smallIntegerTag, 1},
+		"tuple":       {versionTag, largeTupleTag, 0xff, 0xff, 0xff, 0xff},
+		"binary":      {versionTag, binaryTag, 0xff, 0xff, 0xff, 0xff, 'a'},
+		"big integer": {versionTag, largeBigTag, 0xff, 0xff, 0xff, 0xff, 0},
+	}
+
+	for name, term := range terms {
+		t.Run(name, func(t *testing.T) {
+			var v any
+			err := Unmarshal(term, &v)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			var list []int
+			err = Unmarshal(term, &list)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			var m map[string]int
+			err = Unmarshal(term, &m)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	var stream []byte
+	for _, name := range []string{"first", "second"} {
+		term, err := Marshal(map[string]any{"name": name, "ids": []any{json.Number("1149071652235489301")}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream = append(stream, term...)
+	}
+
+	decoder := NewDecoder(bytes.NewReader(stream))
+	for _, want := range []string{"first", "second"} {
+		var got struct {
+			Name string   `json:"name"`
+			IDs  []string `json:"ids"`
+		}
+		err := decoder.Decode(&got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != want || len(got.IDs) != 1 || got.IDs[0] != "1149071652235489301" {
+			t.Fatalf("got %+v, want %s", got, want)
+		}
+	}
+
+	var v any
+	err := decoder.Decode(&v)
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("got error %v, want %v", err, io.EOF)
+	}
+}
+
+func TestDecoderCorruptLength(t *testing.T) {
+	// A binary claiming 4 GiB followed by a few bytes must fail once the stream ends, without allocating the 4 GiB.
+	stream := append([]byte{versionTag, binaryTag, 0xff, 0xff, 0xff, 0xff}, strings.Repeat("a", 100)...)
+
+	var v any
+	err := NewDecoder(bytes.NewReader(stream)).Decode(&v)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("got error %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
@@ -0,0 +1,158 @@
+package etf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+const readChunkSize = 64 * 1024
+
+// Decoder reads terms one after another from a stream, e.g. a decompressed gateway connection.
+type Decoder struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		r: bufio.NewReader(r),
+	}
+}
+
+// Decode reads the next term and unmarshals it into v.
+func (d *Decoder) Decode(v any) error {
+	d.buf = d.buf[:0]
+
+	version, err := d.read(1)
+	if err != nil {
+		return err
+	}
+	if version[0] != versionTag {
+		return fmt.Errorf("etf: unsupported version %d", version[0])
+	}
+
+	err = d.term()
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	return Unmarshal(d.buf, v)
+}
+
+// read appends the next n bytes of the stream to the term and returns them. The term grows as the bytes arrive,
+// so a corrupt length does not allocate more than the stream holds.
+func (d *Decoder) read(n int) ([]byte, error) {
+	start := len(d.buf)
+
+	for len(d.buf)-start < n {
+		chunk := n - (len(d.buf) - start)
+		if chunk > readChunkSize {
+			chunk = readChunkSize
+		}
+
+		d.buf = append(d.buf, make([]byte, chunk)...)
+
+		_, err := io.ReadFull(d.r, d.buf[len(d.buf)-chunk:])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return d.buf[start:], nil
+}
+
+func (d *Decoder) length(size int) (int, error) {
+	b, err := d.read(size)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, x := range b {
+		n = n<<8 | int(x)
+	}
+
+	return n, nil
+}
+
+// term copies the next term from the stream without decoding it.
+func (d *Decoder) term() error {
+	b, err := d.read(1)
+	if err != nil {
+		return err
+	}
+
+	tag := b[0]
+	switch tag {
+	case smallIntegerTag:
+		_, err = d.read(1)
+	case integerTag:
+		_, err = d.read(4)
+	case newFloatTag:
+		_, err = d.read(8)
+	case floatTag:
+		_, err = d.read(31)
+	case smallBigTag, largeBigTag:
+		var n int
+		if tag == smallBigTag {
+			n, err = d.length(1)
+		} else {
+			n, err = d.length(4)
+		}
+		if err == nil {
+			// The sign byte precedes the digits.
+			_, err = d.read(n + 1)
+		}
+	case smallAtomTag, smallAtomUTF8Tag:
+		err = d.bytes(1, 0)
+	case atomTag, atomUTF8Tag, stringTag:
+		err = d.bytes(2, 0)
+	case binaryTag:
+		err = d.bytes(4, 0)
+	case bitBinaryTag:
+		err = d.bytes(4, 1)
+	case nilTag:
+	case listTag:
+		var n int
+		n, err = d.length(4)
+		for i := 0; i <= n && err == nil; i++ {
+			err = d.term()
+		}
+	case smallTupleTag, largeTupleTag:
+		var n int
+		if tag == smallTupleTag {
+			n, err = d.length(1)
+		} else {
+			n, err = d.length(4)
+		}
+		for i := 0; i < n && err == nil; i++ {
+			err = d.term()
+		}
+	case mapTag:
+		var n int
+		n, err = d.length(4)
+		for i := 0; i < 2*n && err == nil; i++ {
+			err = d.term()
+		}
+	default:
+		err = fmt.Errorf("etf: unknown tag %d", tag)
+	}
+
+	return err
+}
+
+// bytes copies a length prefixed sequence of bytes, extra is the number of bytes between the length and the data.
+func (d *Decoder) bytes(size int, extra int) error {
+	n, err := d.length(size)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.read(n + extra)
+
+	return err
+}
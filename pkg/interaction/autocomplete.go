@@ -0,0 +1,77 @@
+package interaction
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bsponge/discordGopher/pkg/object"
+)
+
+// autocompleteTimeout leaves time to send the choices before the 3 second deadline of the interaction.
+const autocompleteTimeout = 2500 * time.Millisecond
+
+type autocompleteResult struct {
+	choices []object.ApplicationCommandOptionChoice
+	err     error
+}
+
+// autocomplete answers with the choices of the function registered for the focused option. When the function
+// fails or does not finish in time, an empty list is sent so that the user is not left waiting.
+func (r *Router) autocomplete(ctx context.Context, event *Event, path string) error {
+	focused, ok := event.Focused()
+	if !ok {
+		return fmt.Errorf("autocomplete interaction %s has no focused option", event.ID)
+	}
+
+	r.mtx.RLock()
+	fn, ok := r.autocompletes[autocompleteKey{path: path, option: focused.Name}]
+	r.mtx.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("no autocomplete registered for %s option of %s command", focused.Name, path)
+	}
+
+	fnCtx, cancel := context.WithTimeout(ctx, autocompleteTimeout)
+	defer cancel()
+
+	resultCh := make(chan autocompleteResult, 1)
+	go func() {
+		choices, err := fn(fnCtx, event, partialValue(focused.Value))
+		resultCh <- autocompleteResult{choices: choices, err: err}
+	}()
+
+	var result autocompleteResult
+	select {
+	case result = <-resultCh:
+	case <-fnCtx.Done():
+		result.err = fmt.Errorf("autocomplete of %s option of %s command timed out", focused.Name, path)
+	}
+
+	choices := result.choices
+	if result.err != nil {
+		choices = nil
+	}
+
+	if len(choices) > object.AutocompleteChoicesLimit {
+		choices = choices[:object.AutocompleteChoicesLimit]
+	}
+
+	err := event.Autocomplete(ctx, choices)
+	if result.err != nil {
+		return result.err
+	}
+
+	return err
+}
+
+// partialValue returns what the user has typed so far, string values are sent quoted and numbers as they are.
+func partialValue(raw json.RawMessage) string {
+	var value string
+	if json.Unmarshal(raw, &value) == nil {
+		return value
+	}
+
+	return string(raw)
+}
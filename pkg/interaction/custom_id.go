@@ -0,0 +1,21 @@
+package interaction
+
+import (
+	"strings"
+)
+
+// customIDSeparator separates the name of a component handler from its parameters in custom IDs,
+// e.g. "player:skipto:3" routes to the "player" handler with the "skipto" and "3" parameters.
+const customIDSeparator = ":"
+
+// CustomID builds the custom ID of a component. The name must not contain the separator and the whole ID
+// must fit in object.CustomIDLimit characters.
+func CustomID(name string, params ...string) string {
+	return strings.Join(append([]string{name}, params...), customIDSeparator)
+}
+
+func parseCustomID(customID string) (string, []string) {
+	parts := strings.Split(customID, customIDSeparator)
+
+	return parts[0], parts[1:]
+}
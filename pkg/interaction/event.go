@@ -0,0 +1,283 @@
+package interaction
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/bsponge/discordGopher/pkg/object"
+	"github.com/bsponge/discordGopher/pkg/rest"
+)
+
+// Responder sends the initial response of an interaction. Over the gateway it is a REST callback,
+// over the HTTP endpoint it is the body of the HTTP response.
+type Responder func(ctx context.Context, response *object.InteractionResponse) error
+
+// RESTResponder answers the interaction with the interaction callback endpoint.
+func RESTResponder(client *rest.Client, interaction *object.Interaction) Responder {
+	return func(ctx context.Context, response *object.InteractionResponse) error {
+		return client.CreateInteractionResponse(ctx, interaction.ID, interaction.Token, response)
+	}
+}
+
+// Event is an interaction being handled. Every interaction has to be responded to exactly once within 3 seconds,
+// either with a message or with a deferred response followed by EditOriginal within 15 minutes.
+type Event struct {
+	*object.Interaction
+
+	rest    *rest.Client
+	respond Responder
+	options []object.ApplicationCommandInteractionDataOption
+	params  []string
+
+	mtx       sync.Mutex
+	responded bool
+}
+
+func newEvent(client *rest.Client, interaction *object.Interaction, respond Responder) *Event {
+	return &Event{
+		Interaction: interaction,
+		rest:        client,
+		respond:     respond,
+	}
+}
+
+// Respond sends the initial response. Use the helpers below unless the callback type has no helper.
+func (e *Event) Respond(ctx context.Context, response *object.InteractionResponse) error {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if e.responded {
+		return fmt.Errorf("interaction %s has already been responded to", e.ID)
+	}
+
+	err := e.respond(ctx, response)
+	if err != nil {
+		return err
+	}
+
+	e.responded = true
+
+	return nil
+}
+
+func (e *Event) Responded() bool {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	return e.responded
+}
+
+// Reply responds with a message.
+func (e *Event) Reply(ctx context.Context, data *object.InteractionCallbackData) error {
+	return e.Respond(ctx, &object.InteractionResponse{
+		Type: object.ChannelMessageWithSourceCallback,
+		Data: data,
+	})
+}
+
+// ReplyEphemeral responds with a message only the invoking user can see.
+func (e *Event) ReplyEphemeral(ctx context.Context, content string) error {
+	return e.Reply(ctx, &object.InteractionCallbackData{
+		Content: content,
+		Flags:   object.EphemeralMessageFlag,
+	})
+}
+
+// Defer acknowledges the interaction, the user sees a loading state until EditOriginal is called.
+func (e *Event) Defer(ctx context.Context, ephemeral bool) error {
+	response := &object.InteractionResponse{
+		Type: object.DeferredChannelMessageWithSourceCallback,
+	}
+
+	if ephemeral {
+		response.Data = &object.InteractionCallbackData{
+			Flags: object.EphemeralMessageFlag,
+		}
+	}
+
+	return e.Respond(ctx, response)
+}
+
+// DeferUpdate acknowledges a component interaction without changing the message it is attached to yet.
+func (e *Event) DeferUpdate(ctx context.Context) error {
+	return e.Respond(ctx, &object.InteractionResponse{
+		Type: object.DeferredUpdateMessageCallback,
+	})
+}
+
+// Update edits the message the component is attached to.
+func (e *Event) Update(ctx context.Context, data *object.InteractionCallbackData) error {
+	return e.Respond(ctx, &object.InteractionResponse{
+		Type: object.UpdateMessageCallback,
+		Data: data,
+	})
+}
+
+// Autocomplete responds with the suggested choices of the focused option.
+func (e *Event) Autocomplete(ctx context.Context, choices []object.ApplicationCommandOptionChoice) error {
+	if choices == nil {
+		choices = []object.ApplicationCommandOptionChoice{}
+	}
+
+	return e.Respond(ctx, &object.InteractionResponse{
+		Type: object.ApplicationCommandAutocompleteCallback,
+		Data: &object.InteractionCallbackData{
+			Choices: &choices,
+		},
+	})
+}
+
+// Modal opens a popup with the given text inputs, each placed in its own action row. Its submission is routed
+// to the handler registered with Router.Modal under the name used in customID.
+func (e *Event) Modal(ctx context.Context, customID string, title string, inputs ...object.Component) error {
+	rows := make([]object.Component, 0, len(inputs))
+	for _, input := range inputs {
+		rows = append(rows, object.ActionRow(input))
+	}
+
+	return e.Respond(ctx, &object.InteractionResponse{
+		Type: object.ModalCallback,
+		Data: &object.InteractionCallbackData{
+			CustomID:   customID,
+			Title:      title,
+			Components: rows,
+		},
+	})
+}
+
+func (e *Event) GetOriginal(ctx context.Context) (*object.Message, error) {
+	return e.rest.GetOriginalInteractionResponse(ctx, e.ApplicationID, e.Token)
+}
+
+func (e *Event) EditOriginal(ctx context.Context, params *rest.MessageEditParams) (*object.Message, error) {
+	return e.rest.EditOriginalInteractionResponse(ctx, e.ApplicationID, e.Token, params)
+}
+
+func (e *Event) DeleteOriginal(ctx context.Context) error {
+	return e.rest.DeleteOriginalInteractionResponse(ctx, e.ApplicationID, e.Token)
+}
+
+func (e *Event) Followup(ctx context.Context, params *rest.MessageCreateParams) (*object.Message, error) {
+	return e.rest.CreateFollowupMessage(ctx, e.ApplicationID, e.Token, params)
+}
+
+func (e *Event) EditFollowup(ctx context.Context, messageID string, params *rest.MessageEditParams) (*object.Message, error) {
+	return e.rest.EditFollowupMessage(ctx, e.ApplicationID, e.Token, messageID, params)
+}
+
+func (e *Event) DeleteFollowup(ctx context.Context, messageID string) error {
+	return e.rest.DeleteFollowupMessage(ctx, e.ApplicationID, e.Token, messageID)
+}
+
+// Options returns the options of the invoked command, or of the invoked subcommand if there is one.
+func (e *Event) Options() []object.ApplicationCommandInteractionDataOption {
+	return e.options
+}
+
+func (e *Event) Option(name string) (*object.ApplicationCommandInteractionDataOption, bool) {
+	for i := range e.options {
+		if e.options[i].Name == name {
+			return &e.options[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// Focused returns the option the user is typing in during autocomplete.
+func (e *Event) Focused() (*object.ApplicationCommandInteractionDataOption, bool) {
+	for i := range e.options {
+		if e.options[i].Focused {
+			return &e.options[i], true
+		}
+	}
+
+	return nil, false
+}
+
+func (e *Event) StringOption(name string) (string, bool) {
+	var value string
+	ok := e.optionValue(name, &value)
+
+	return value, ok
+}
+
+func (e *Event) IntOption(name string) (int64, bool) {
+	var value int64
+	ok := e.optionValue(name, &value)
+
+	return value, ok
+}
+
+func (e *Event) FloatOption(name string) (float64, bool) {
+	var value float64
+	ok := e.optionValue(name, &value)
+
+	return value, ok
+}
+
+func (e *Event) BoolOption(name string) (bool, bool) {
+	var value bool
+	ok := e.optionValue(name, &value)
+
+	return value, ok
+}
+
+func (e *Event) optionValue(name string, value any) bool {
+	option, ok := e.Option(name)
+	if !ok || option.Value == nil {
+		return false
+	}
+
+	return json.Unmarshal(option.Value, value) == nil
+}
+
+// Params returns the parameters encoded in the custom ID of the component.
+func (e *Event) Params() []string {
+	return e.params
+}
+
+// Param returns the parameter at the given index or an empty string if there is none.
+func (e *Event) Param(index int) string {
+	if index < 0 || index >= len(e.params) {
+		return ""
+	}
+
+	return e.params[index]
+}
+
+// Values returns the values chosen in a select menu.
+func (e *Event) Values() []string {
+	if e.Data == nil {
+		return nil
+	}
+
+	return e.Data.Values
+}
+
+// ModalValues returns the submitted values of the text inputs of a modal keyed by their custom IDs.
+func (e *Event) ModalValues() map[string]string {
+	values := make(map[string]string)
+	if e.Data == nil {
+		return values
+	}
+
+	for _, row := range e.Data.Components {
+		for _, component := range row.Components {
+			if component.Type == object.TextInputComponent {
+				values[component.CustomID] = component.Value
+			}
+		}
+	}
+
+	return values
+}
+
+// ModalValue returns the submitted value of the text input with the given custom ID.
+func (e *Event) ModalValue(customID string) (string, bool) {
+	value, ok := e.ModalValues()[customID]
+
+	return value, ok
+}
@@ -0,0 +1,144 @@
+package interaction
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bsponge/discordGopher/pkg/log"
+	"github.com/bsponge/discordGopher/pkg/object"
+)
+
+const (
+	headerSignature = "X-Signature-Ed25519"
+	headerTimestamp = "X-Signature-Timestamp"
+
+	maxRequestBodySize = 4 << 20
+	// responseTimeout leaves some slack before Discord gives up on the request after 3 seconds.
+	responseTimeout = 2900 * time.Millisecond
+)
+
+// ParsePublicKey decodes the hex encoded public key of the application, object.Application.VerifyKey.
+func ParsePublicKey(key string) (ed25519.PublicKey, error) {
+	decoded, err := hex.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode public key: %w", err)
+	}
+
+	if len(decoded) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key is %d bytes long, expected %d", len(decoded), ed25519.PublicKeySize)
+	}
+
+	return ed25519.PublicKey(decoded), nil
+}
+
+type httpHandler struct {
+	ctx       context.Context
+	router    *Router
+	publicKey ed25519.PublicKey
+}
+
+// NewHTTPHandler returns the handler of the interactions endpoint URL. Requests are verified with the public key
+// of the application and the verified interactions are routed by the router. The interaction handlers keep
+// running with ctx after the HTTP response has been sent, so that they can send follow-up messages.
+func NewHTTPHandler(ctx context.Context, router *Router, publicKey ed25519.PublicKey) http.Handler {
+	return &httpHandler{
+		ctx:       ctx,
+		router:    router,
+		publicKey: publicKey,
+	}
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodySize))
+	if err != nil {
+		http.Error(w, "could not read request body", http.StatusBadRequest)
+		return
+	}
+
+	if !h.verify(r.Header.Get(headerSignature), r.Header.Get(headerTimestamp), body) {
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+
+	var i object.Interaction
+	err = json.Unmarshal(body, &i)
+	if err != nil {
+		http.Error(w, "invalid interaction", http.StatusBadRequest)
+		return
+	}
+
+	logger := log.Logger().WithField("interaction_id", i.ID)
+
+	responses := make(chan *object.InteractionResponse)
+	answered := make(chan struct{})
+	defer close(answered)
+
+	// The initial response becomes the body of the HTTP response.
+	respond := func(ctx context.Context, response *object.InteractionResponse) error {
+		select {
+		case responses <- response:
+			return nil
+		case <-answered:
+			return fmt.Errorf("the request of interaction %s has already been answered", i.ID)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+
+		err := h.router.Handle(h.ctx, &i, respond)
+		if err != nil {
+			logger.WithError(err).Error("Could not handle interaction")
+		}
+	}()
+
+	timer := time.NewTimer(responseTimeout)
+	defer timer.Stop()
+
+	select {
+	case response := <-responses:
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(response)
+		if err != nil {
+			logger.WithError(err).Error("Could not write interaction response")
+		}
+	case <-finished:
+		http.Error(w, "interaction was not responded to", http.StatusInternalServerError)
+	case <-timer.C:
+		logger.Error("Interaction was not responded to in time")
+		http.Error(w, "interaction was not responded to in time", http.StatusServiceUnavailable)
+	case <-r.Context().Done():
+	}
+}
+
+// verify checks the Ed25519 signature of the timestamp followed by the body.
+func (h *httpHandler) verify(signature string, timestamp string, body []byte) bool {
+	if signature == "" || timestamp == "" {
+		return false
+	}
+
+	decoded, err := hex.DecodeString(signature)
+	if err != nil || len(decoded) != ed25519.SignatureSize {
+		return false
+	}
+
+	message := make([]byte, 0, len(timestamp)+len(body))
+	message = append(message, timestamp...)
+	message = append(message, body...)
+
+	return ed25519.Verify(h.publicKey, message, decoded)
+}
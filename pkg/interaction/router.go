@@ -0,0 +1,173 @@
+package interaction
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bsponge/discordGopher/pkg/object"
+	"github.com/bsponge/discordGopher/pkg/rest"
+)
+
+type Handler func(ctx context.Context, event *Event) error
+
+// AutocompleteFunc suggests choices for the partial value of the focused option. The context expires shortly
+// before the interaction deadline, choices past object.AutocompleteChoicesLimit are dropped.
+type AutocompleteFunc func(ctx context.Context, event *Event, value string) ([]object.ApplicationCommandOptionChoice, error)
+
+type autocompleteKey struct {
+	path   string
+	option string
+}
+
+// Router dispatches interactions to the registered handlers. The same router serves the gateway
+// and the HTTP endpoint, only the Responder differs.
+type Router struct {
+	rest *rest.Client
+
+	mtx        sync.RWMutex
+	commands   map[string]Handler
+	components map[string]Handler
+	modals     map[string]Handler
+
+	autocompletes map[autocompleteKey]AutocompleteFunc
+}
+
+func NewRouter(client *rest.Client) *Router {
+	return &Router{
+		rest:       client,
+		commands:   make(map[string]Handler),
+		components: make(map[string]Handler),
+		modals:     make(map[string]Handler),
+
+		autocompletes: make(map[autocompleteKey]AutocompleteFunc),
+	}
+}
+
+// Command registers the handler of the command. The path is the command name followed by the names of
+// the subcommand group and the subcommand, if any, e.g. "queue" or "playlist create".
+func (r *Router) Command(path string, handler Handler) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.commands[path] = handler
+}
+
+// Component registers the handler of the components whose custom IDs were built with CustomID and the given name.
+func (r *Router) Component(name string, handler Handler) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.components[name] = handler
+}
+
+// Modal registers the handler of the modals whose custom IDs were built with CustomID and the given name.
+func (r *Router) Modal(name string, handler Handler) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.modals[name] = handler
+}
+
+// Autocomplete registers the function suggesting the values of the option of the command, the path is the same
+// as in Command. The option has to be declared with Autocomplete set.
+func (r *Router) Autocomplete(path string, option string, fn AutocompleteFunc) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.autocompletes[autocompleteKey{path: path, option: option}] = fn
+}
+
+// Handle routes the interaction to its handler and answers pings. When the handler fails before responding,
+// the error is sent to the user as an ephemeral message.
+func (r *Router) Handle(ctx context.Context, interaction *object.Interaction, respond Responder) error {
+	event := newEvent(r.rest, interaction, respond)
+
+	switch interaction.Type {
+	case object.PingInteraction:
+		return event.Respond(ctx, &object.InteractionResponse{Type: object.PongCallback})
+	case object.ApplicationCommandInteraction:
+		if interaction.Data == nil {
+			return fmt.Errorf("application command interaction %s has no data", interaction.ID)
+		}
+
+		path, options := commandPath(interaction.Data)
+		event.options = options
+
+		r.mtx.RLock()
+		handler, ok := r.commands[path]
+		r.mtx.RUnlock()
+
+		if !ok {
+			return fmt.Errorf("no handler registered for %s command", path)
+		}
+
+		return r.run(ctx, event, path, handler)
+	case object.ApplicationCommandAutocompleteInteraction:
+		if interaction.Data == nil {
+			return fmt.Errorf("autocomplete interaction %s has no data", interaction.ID)
+		}
+
+		path, options := commandPath(interaction.Data)
+		event.options = options
+
+		return r.autocomplete(ctx, event, path)
+	case object.MessageComponentInteraction:
+		return r.routeCustomID(ctx, event, "component", r.components)
+	case object.ModalSubmitInteraction:
+		return r.routeCustomID(ctx, event, "modal", r.modals)
+	default:
+		return fmt.Errorf("unsupported interaction type %d", interaction.Type)
+	}
+}
+
+// routeCustomID runs the handler registered under the name of the custom ID with its parameters.
+func (r *Router) routeCustomID(ctx context.Context, event *Event, kind string, handlers map[string]Handler) error {
+	if event.Data == nil {
+		return fmt.Errorf("%s interaction %s has no data", kind, event.ID)
+	}
+
+	name, params := parseCustomID(event.Data.CustomID)
+	event.params = params
+
+	r.mtx.RLock()
+	handler, ok := handlers[name]
+	r.mtx.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("no handler registered for %s %s", name, kind)
+	}
+
+	return r.run(ctx, event, name, handler)
+}
+
+func (r *Router) run(ctx context.Context, event *Event, path string, handler Handler) error {
+	err := handler(ctx, event)
+	if err == nil {
+		return nil
+	}
+
+	if !event.Responded() {
+		replyErr := event.ReplyEphemeral(ctx, err.Error())
+		if replyErr != nil {
+			return fmt.Errorf("%s handler failed: %w, could not report the error: %v", path, err, replyErr)
+		}
+	}
+
+	return fmt.Errorf("%s handler failed: %w", path, err)
+}
+
+// commandPath returns the routing key of the invoked command and the options of its innermost subcommand.
+func commandPath(data *object.InteractionData) (string, []object.ApplicationCommandInteractionDataOption) {
+	path := []string{data.Name}
+	options := data.Options
+
+	for len(options) == 1 &&
+		(options[0].Type == object.SubCommandOption || options[0].Type == object.SubCommandGroupOption) {
+		path = append(path, options[0].Name)
+		options = options[0].Options
+	}
+
+	return strings.Join(path, " "), options
+}
@@ -0,0 +1,84 @@
+package object
+
+type ApplicationCommandType int
+type ApplicationCommandOptionType int
+type ApplicationCommandPermissionType int
+
+const (
+	ChatInputCommand ApplicationCommandType = 1
+	UserCommand      ApplicationCommandType = 2
+	MessageCommand   ApplicationCommandType = 3
+
+	SubCommandOption      ApplicationCommandOptionType = 1
+	SubCommandGroupOption ApplicationCommandOptionType = 2
+	StringOption          ApplicationCommandOptionType = 3
+	IntegerOption         ApplicationCommandOptionType = 4
+	BooleanOption         ApplicationCommandOptionType = 5
+	UserOption            ApplicationCommandOptionType = 6
+	ChannelOption         ApplicationCommandOptionType = 7
+	RoleOption            ApplicationCommandOptionType = 8
+	MentionableOption     ApplicationCommandOptionType = 9
+	NumberOption          ApplicationCommandOptionType = 10
+	AttachmentOption      ApplicationCommandOptionType = 11
+
+	RolePermission    ApplicationCommandPermissionType = 1
+	UserPermission    ApplicationCommandPermissionType = 2
+	ChannelPermission ApplicationCommandPermissionType = 3
+
+	AutocompleteChoicesLimit = 25
+)
+
+// ApplicationCommand is a slash, user or message command. Localization maps are keyed by locale, e.g. "pl".
+type ApplicationCommand struct {
+	ID                       string                     `json:"id,omitempty"`
+	Type                     ApplicationCommandType     `json:"type,omitempty"`
+	ApplicationID            string                     `json:"application_id,omitempty"`
+	GuildID                  *string                    `json:"guild_id,omitempty"`
+	Name                     string                     `json:"name"`
+	NameLocalizations        map[string]string          `json:"name_localizations,omitempty"`
+	Description              string                     `json:"description"`
+	DescriptionLocalizations map[string]string          `json:"description_localizations,omitempty"`
+	Options                  []ApplicationCommandOption `json:"options,omitempty"`
+	DefaultMemberPermissions *string                    `json:"default_member_permissions,omitempty"`
+	DMPermission             *bool                      `json:"dm_permission,omitempty"`
+	NSFW                     bool                       `json:"nsfw,omitempty"`
+	Version                  string                     `json:"version,omitempty"`
+}
+
+type ApplicationCommandOption struct {
+	Type                     ApplicationCommandOptionType     `json:"type"`
+	Name                     string                           `json:"name"`
+	NameLocalizations        map[string]string                `json:"name_localizations,omitempty"`
+	Description              string                           `json:"description"`
+	DescriptionLocalizations map[string]string                `json:"description_localizations,omitempty"`
+	Required                 bool                             `json:"required,omitempty"`
+	Choices                  []ApplicationCommandOptionChoice `json:"choices,omitempty"`
+	Options                  []ApplicationCommandOption       `json:"options,omitempty"`
+	ChannelTypes             []ChannelType                    `json:"channel_types,omitempty"`
+	MinValue                 *float64                         `json:"min_value,omitempty"`
+	MaxValue                 *float64                         `json:"max_value,omitempty"`
+	MinLength                *int                             `json:"min_length,omitempty"`
+	MaxLength                *int                             `json:"max_length,omitempty"`
+	Autocomplete             bool                             `json:"autocomplete,omitempty"`
+}
+
+// ApplicationCommandOptionChoice is a predefined value of an option. Value is a string, an integer or a number
+// depending on the type of the option.
+type ApplicationCommandOptionChoice struct {
+	Name              string            `json:"name"`
+	NameLocalizations map[string]string `json:"name_localizations,omitempty"`
+	Value             any               `json:"value"`
+}
+
+type GuildApplicationCommandPermissions struct {
+	ID            string                         `json:"id"`
+	ApplicationID string                         `json:"application_id"`
+	GuildID       string                         `json:"guild_id"`
+	Permissions   []ApplicationCommandPermission `json:"permissions"`
+}
+
+type ApplicationCommandPermission struct {
+	ID         string                           `json:"id"`
+	Type       ApplicationCommandPermissionType `json:"type"`
+	Permission bool                             `json:"permission"`
+}
@@ -0,0 +1,124 @@
+package object
+
+type ComponentType int
+
+const (
+	ActionRowComponent         ComponentType = 1
+	ButtonComponent            ComponentType = 2
+	StringSelectComponent      ComponentType = 3
+	TextInputComponent         ComponentType = 4
+	UserSelectComponent        ComponentType = 5
+	RoleSelectComponent        ComponentType = 6
+	MentionableSelectComponent ComponentType = 7
+	ChannelSelectComponent     ComponentType = 8
+
+	PrimaryButton   int = 1
+	SecondaryButton int = 2
+	SuccessButton   int = 3
+	DangerButton    int = 4
+	LinkButton      int = 5
+
+	ShortTextInput     int = 1
+	ParagraphTextInput int = 2
+
+	ActionRowComponentsLimit = 5
+	SelectOptionsLimit       = 25
+	CustomIDLimit            = 100
+)
+
+// Component is a message component. It has the fields of all component types, only the ones relevant to Type
+// are sent. Messages hold up to 5 action rows, each with up to 5 buttons or a single select menu, modals hold up to
+// 5 action rows with a single text input each.
+type Component struct {
+	Type     ComponentType `json:"type"`
+	CustomID string        `json:"custom_id,omitempty"`
+	Disabled bool          `json:"disabled,omitempty"`
+
+	Style int           `json:"style,omitempty"`
+	Label string        `json:"label,omitempty"`
+	Emoji *PartialEmoji `json:"emoji,omitempty"`
+	URL   string        `json:"url,omitempty"`
+
+	Options      []SelectOption `json:"options,omitempty"`
+	ChannelTypes []ChannelType  `json:"channel_types,omitempty"`
+	Placeholder  string         `json:"placeholder,omitempty"`
+	MinValues    *int           `json:"min_values,omitempty"`
+	MaxValues    int            `json:"max_values,omitempty"`
+
+	MinLength *int   `json:"min_length,omitempty"`
+	MaxLength int    `json:"max_length,omitempty"`
+	Required  *bool  `json:"required,omitempty"`
+	Value     string `json:"value,omitempty"`
+
+	Components []Component `json:"components,omitempty"`
+}
+
+type SelectOption struct {
+	Label       string        `json:"label"`
+	Value       string        `json:"value"`
+	Description string        `json:"description,omitempty"`
+	Emoji       *PartialEmoji `json:"emoji,omitempty"`
+	Default     bool          `json:"default,omitempty"`
+}
+
+// PartialEmoji is either a custom emoji, identified by ID, or a unicode one, identified by Name.
+type PartialEmoji struct {
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Animated bool   `json:"animated,omitempty"`
+}
+
+func ActionRow(components ...Component) Component {
+	return Component{
+		Type:       ActionRowComponent,
+		Components: components,
+	}
+}
+
+func Button(style int, label string, customID string) Component {
+	return Component{
+		Type:     ButtonComponent,
+		Style:    style,
+		Label:    label,
+		CustomID: customID,
+	}
+}
+
+// URLButton opens the URL instead of sending an interaction.
+func URLButton(label string, url string) Component {
+	return Component{
+		Type:  ButtonComponent,
+		Style: LinkButton,
+		Label: label,
+		URL:   url,
+	}
+}
+
+func StringSelect(customID string, placeholder string, options ...SelectOption) Component {
+	return Component{
+		Type:        StringSelectComponent,
+		CustomID:    customID,
+		Placeholder: placeholder,
+		Options:     options,
+	}
+}
+
+// EntitySelect creates a user, role, mentionable or channel select, whose options are filled in by Discord.
+func EntitySelect(componentType ComponentType, customID string, placeholder string) Component {
+	return Component{
+		Type:        componentType,
+		CustomID:    customID,
+		Placeholder: placeholder,
+	}
+}
+
+// TextInput creates a text input of a modal, ShortTextInput or ParagraphTextInput. Its submitted value is sent
+// back in Value.
+func TextInput(customID string, label string, style int) Component {
+	return Component{
+		Type:     TextInputComponent,
+		CustomID: customID,
+		Label:    label,
+		Style:    style,
+	}
+}
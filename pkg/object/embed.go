@@ -0,0 +1,214 @@
+package object
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	EmbedTitleLimit       = 256
+	EmbedDescriptionLimit = 4096
+	EmbedFieldsLimit      = 25
+	EmbedFieldNameLimit   = 256
+	EmbedFieldValueLimit  = 1024
+	EmbedFooterTextLimit  = 2048
+	EmbedAuthorNameLimit  = 256
+	EmbedTotalLimit       = 6000
+)
+
+type Embed struct {
+	Title       string          `json:"title,omitempty"`
+	Type        string          `json:"type,omitempty"`
+	Description string          `json:"description,omitempty"`
+	URL         string          `json:"url,omitempty"`
+	Timestamp   string          `json:"timestamp,omitempty"`
+	Color       int             `json:"color,omitempty"`
+	Footer      *EmbedFooter    `json:"footer,omitempty"`
+	Image       *EmbedImage     `json:"image,omitempty"`
+	Thumbnail   *EmbedThumbnail `json:"thumbnail,omitempty"`
+	Video       *EmbedVideo     `json:"video,omitempty"`
+	Provider    *EmbedProvider  `json:"provider,omitempty"`
+	Author      *EmbedAuthor    `json:"author,omitempty"`
+	Fields      []EmbedField    `json:"fields,omitempty"`
+}
+
+type EmbedFooter struct {
+	Text         string `json:"text"`
+	IconURL      string `json:"icon_url,omitempty"`
+	ProxyIconURL string `json:"proxy_icon_url,omitempty"`
+}
+
+type EmbedImage struct {
+	URL      string `json:"url"`
+	ProxyURL string `json:"proxy_url,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	Width    int    `json:"width,omitempty"`
+}
+
+type EmbedThumbnail struct {
+	URL      string `json:"url"`
+	ProxyURL string `json:"proxy_url,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	Width    int    `json:"width,omitempty"`
+}
+
+type EmbedVideo struct {
+	URL      string `json:"url,omitempty"`
+	ProxyURL string `json:"proxy_url,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	Width    int    `json:"width,omitempty"`
+}
+
+type EmbedProvider struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+type EmbedAuthor struct {
+	Name         string `json:"name"`
+	URL          string `json:"url,omitempty"`
+	IconURL      string `json:"icon_url,omitempty"`
+	ProxyIconURL string `json:"proxy_icon_url,omitempty"`
+}
+
+type EmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// Validate checks the embed against the length limits enforced by Discord.
+func (e *Embed) Validate() error {
+	total := 0
+
+	check := func(name string, value string, limit int) error {
+		length := utf8.RuneCountInString(value)
+		if length > limit {
+			return fmt.Errorf("embed %s is %d characters long, the limit is %d", name, length, limit)
+		}
+
+		total += length
+		return nil
+	}
+
+	err := check("title", e.Title, EmbedTitleLimit)
+	if err != nil {
+		return err
+	}
+
+	err = check("description", e.Description, EmbedDescriptionLimit)
+	if err != nil {
+		return err
+	}
+
+	if len(e.Fields) > EmbedFieldsLimit {
+		return fmt.Errorf("embed has %d fields, the limit is %d", len(e.Fields), EmbedFieldsLimit)
+	}
+
+	for i, field := range e.Fields {
+		err = check(fmt.Sprintf("field %d name", i), field.Name, EmbedFieldNameLimit)
+		if err != nil {
+			return err
+		}
+
+		err = check(fmt.Sprintf("field %d value", i), field.Value, EmbedFieldValueLimit)
+		if err != nil {
+			return err
+		}
+	}
+
+	if e.Footer != nil {
+		err = check("footer text", e.Footer.Text, EmbedFooterTextLimit)
+		if err != nil {
+			return err
+		}
+	}
+
+	if e.Author != nil {
+		err = check("author name", e.Author.Name, EmbedAuthorNameLimit)
+		if err != nil {
+			return err
+		}
+	}
+
+	if total > EmbedTotalLimit {
+		return fmt.Errorf("embed is %d characters long in total, the limit is %d", total, EmbedTotalLimit)
+	}
+
+	return nil
+}
+
+// EmbedBuilder builds an embed step by step, e.g.
+//
+//	embed, err := object.NewEmbedBuilder().Title("Now playing").Field("Track", name, true).Build()
+type EmbedBuilder struct {
+	embed Embed
+}
+
+func NewEmbedBuilder() *EmbedBuilder {
+	return &EmbedBuilder{}
+}
+
+func (b *EmbedBuilder) Title(title string) *EmbedBuilder {
+	b.embed.Title = title
+	return b
+}
+
+func (b *EmbedBuilder) Description(description string) *EmbedBuilder {
+	b.embed.Description = description
+	return b
+}
+
+func (b *EmbedBuilder) URL(url string) *EmbedBuilder {
+	b.embed.URL = url
+	return b
+}
+
+func (b *EmbedBuilder) Timestamp(timestamp time.Time) *EmbedBuilder {
+	b.embed.Timestamp = timestamp.Format(time.RFC3339)
+	return b
+}
+
+func (b *EmbedBuilder) Color(color int) *EmbedBuilder {
+	b.embed.Color = color
+	return b
+}
+
+func (b *EmbedBuilder) Footer(text string, iconURL string) *EmbedBuilder {
+	b.embed.Footer = &EmbedFooter{Text: text, IconURL: iconURL}
+	return b
+}
+
+func (b *EmbedBuilder) Image(url string) *EmbedBuilder {
+	b.embed.Image = &EmbedImage{URL: url}
+	return b
+}
+
+func (b *EmbedBuilder) Thumbnail(url string) *EmbedBuilder {
+	b.embed.Thumbnail = &EmbedThumbnail{URL: url}
+	return b
+}
+
+func (b *EmbedBuilder) Author(name string, url string, iconURL string) *EmbedBuilder {
+	b.embed.Author = &EmbedAuthor{Name: name, URL: url, IconURL: iconURL}
+	return b
+}
+
+func (b *EmbedBuilder) Field(name string, value string, inline bool) *EmbedBuilder {
+	b.embed.Fields = append(b.embed.Fields, EmbedField{Name: name, Value: value, Inline: inline})
+	return b
+}
+
+// Build validates the embed and returns it.
+func (b *EmbedBuilder) Build() (*Embed, error) {
+	embed := b.embed
+	embed.Fields = append([]EmbedField(nil), b.embed.Fields...)
+
+	err := embed.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &embed, nil
+}
@@ -0,0 +1,107 @@
+package object
+
+// Payloads of the gateway dispatches. Dispatches which are not listed here use the plain entity as their payload:
+// MESSAGE_CREATE and MESSAGE_UPDATE use Message, GUILD_CREATE and GUILD_UPDATE use Guild, GUILD_DELETE uses
+// UnavailableGuild, CHANNEL_* and THREAD_CREATE/UPDATE/DELETE use Channel and INTERACTION_CREATE uses Interaction.
+
+type MessageDelete struct {
+	ID        string  `json:"id"`
+	ChannelID string  `json:"channel_id"`
+	GuildID   *string `json:"guild_id,omitempty"`
+}
+
+type MessageDeleteBulk struct {
+	IDs       []string `json:"ids"`
+	ChannelID string   `json:"channel_id"`
+	GuildID   *string  `json:"guild_id,omitempty"`
+}
+
+type MessageReactionAdd struct {
+	UserID          string       `json:"user_id"`
+	ChannelID       string       `json:"channel_id"`
+	MessageID       string       `json:"message_id"`
+	GuildID         *string      `json:"guild_id,omitempty"`
+	Member          *GuildMember `json:"member,omitempty"`
+	Emoji           Emoji        `json:"emoji"`
+	MessageAuthorID *string      `json:"message_author_id,omitempty"`
+}
+
+type MessageReactionRemove struct {
+	UserID    string  `json:"user_id"`
+	ChannelID string  `json:"channel_id"`
+	MessageID string  `json:"message_id"`
+	GuildID   *string `json:"guild_id,omitempty"`
+	Emoji     Emoji   `json:"emoji"`
+}
+
+type ThreadMetadata struct {
+	Archived            bool    `json:"archived"`
+	AutoArchiveDuration int     `json:"auto_archive_duration"`
+	ArchiveTimestamp    string  `json:"archive_timestamp"`
+	Locked              bool    `json:"locked"`
+	Invitable           *bool   `json:"invitable,omitempty"`
+	CreateTimestamp     *string `json:"create_timestamp,omitempty"`
+}
+
+type ThreadMember struct {
+	ID            *string      `json:"id,omitempty"`
+	UserID        *string      `json:"user_id,omitempty"`
+	JoinTimestamp string       `json:"join_timestamp"`
+	Flags         int          `json:"flags"`
+	Member        *GuildMember `json:"member,omitempty"`
+}
+
+type ThreadListSync struct {
+	GuildID    string         `json:"guild_id"`
+	ChannelIDs []string       `json:"channel_ids,omitempty"`
+	Threads    []Channel      `json:"threads"`
+	Members    []ThreadMember `json:"members"`
+}
+
+type ThreadMemberUpdate struct {
+	ThreadMember
+	GuildID string `json:"guild_id"`
+}
+
+type ThreadMembersUpdate struct {
+	ID               string         `json:"id"`
+	GuildID          string         `json:"guild_id"`
+	MemberCount      int            `json:"member_count"`
+	AddedMembers     []ThreadMember `json:"added_members,omitempty"`
+	RemovedMemberIDs []string       `json:"removed_member_ids,omitempty"`
+}
+
+type PresenceUpdate struct {
+	User         User         `json:"user"`
+	GuildID      string       `json:"guild_id"`
+	Status       string       `json:"status"`
+	Activities   []Activity   `json:"activities"`
+	ClientStatus ClientStatus `json:"client_status"`
+}
+
+type Activity struct {
+	Name          string  `json:"name"`
+	Type          int     `json:"type"`
+	URL           *string `json:"url,omitempty"`
+	CreatedAt     int64   `json:"created_at"`
+	ApplicationID *string `json:"application_id,omitempty"`
+	Details       *string `json:"details,omitempty"`
+	State         *string `json:"state,omitempty"`
+}
+
+type ClientStatus struct {
+	Desktop *string `json:"desktop,omitempty"`
+	Mobile  *string `json:"mobile,omitempty"`
+	Web     *string `json:"web,omitempty"`
+}
+
+type TypingStart struct {
+	ChannelID string       `json:"channel_id"`
+	GuildID   *string      `json:"guild_id,omitempty"`
+	UserID    string       `json:"user_id"`
+	Timestamp int64        `json:"timestamp"`
+	Member    *GuildMember `json:"member,omitempty"`
+}
+
+// Resumed is the payload of the RESUMED dispatch, which carries no data.
+type Resumed struct{}
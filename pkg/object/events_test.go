@@ -0,0 +1,443 @@
+package object
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// readDispatch returns the payload of the gateway message captured in testdata/<dispatch>.json.
+func readDispatch(t *testing.T, dispatch Dispatch) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", string(dispatch)+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var message struct {
+		Type Dispatch        `json:"t"`
+		Data json.RawMessage `json:"d"`
+	}
+	err = json.Unmarshal(data, &message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Type != dispatch {
+		t.Fatalf("fixture holds %s, want %s", message.Type, dispatch)
+	}
+
+	return message.Data
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestDispatchPayloads(t *testing.T) {
+	tests := []struct {
+		dispatch Dispatch
+		payload  any
+		want     any
+	}{
+		{
+			dispatch: MessageDeleteType,
+			payload:  &MessageDelete{},
+			want: &MessageDelete{
+				ID:        "1149071652235489301",
+				ChannelID: "1082683372128055326",
+				GuildID:   stringPtr("1082683371452780544"),
+			},
+		},
+		{
+			dispatch: MessageDeleteBulkType,
+			payload:  &MessageDeleteBulk{},
+			want: &MessageDeleteBulk{
+				IDs:       []string{"1149071652235489301", "1149071660682805298"},
+				ChannelID: "1082683372128055326",
+				GuildID:   stringPtr("1082683371452780544"),
+			},
+		},
+		{
+			dispatch: MessageReactionRemoveType,
+			payload:  &MessageReactionRemove{},
+			want: &MessageReactionRemove{
+				UserID:    "284102390608347136",
+				ChannelID: "1082683372128055326",
+				MessageID: "1149071652235489301",
+				GuildID:   stringPtr("1082683371452780544"),
+				Emoji:     Emoji{ID: "1082690047318560838", Name: "gopher", Animated: boolPtr(true)},
+			},
+		},
+		{
+			dispatch: ThreadMemberUpdateType,
+			payload:  &ThreadMemberUpdate{},
+			want: &ThreadMemberUpdate{
+				ThreadMember: ThreadMember{
+					ID:            stringPtr("1149433121431855195"),
+					UserID:        stringPtr("1082680961921613945"),
+					JoinTimestamp: "2023-09-07T18:03:40.001000+00:00",
+					Flags:         1,
+				},
+				GuildID: "1082683371452780544",
+			},
+		},
+		{
+			dispatch: ResumedType,
+			payload:  &Resumed{},
+			want:     &Resumed{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.dispatch), func(t *testing.T) {
+			err := json.Unmarshal(readDispatch(t, test.dispatch), test.payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(test.payload, test.want) {
+				t.Fatalf("got %+v, want %+v", test.payload, test.want)
+			}
+		})
+	}
+}
+
+func TestMessageReactionAdd(t *testing.T) {
+	var reaction MessageReactionAdd
+	err := json.Unmarshal(readDispatch(t, MessageReactionAddType), &reaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reaction.UserID != "284102390608347136" || reaction.MessageID != "1149071652235489301" ||
+		reaction.ChannelID != "1082683372128055326" || *reaction.GuildID != "1082683371452780544" {
+		t.Fatalf("unexpected ids in %+v", reaction)
+	}
+	if reaction.Emoji.Name != "🔥" || reaction.Emoji.ID != "" {
+		t.Fatalf("got emoji %+v, want the unicode fire emoji", reaction.Emoji)
+	}
+	if *reaction.MessageAuthorID != "1082680961921613945" {
+		t.Fatalf("got message author %s", *reaction.MessageAuthorID)
+	}
+	if reaction.Member == nil || reaction.Member.User.Username != "bsponge" || reaction.Member.Roles[0] != "1082684120517718036" {
+		t.Fatalf("unexpected member %+v", reaction.Member)
+	}
+}
+
+func TestThreadCreate(t *testing.T) {
+	var thread Channel
+	err := json.Unmarshal(readDispatch(t, ThreadCreateType), &thread)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if thread.ID != "1149433121431855195" || thread.Type != 11 || *thread.Name != "queue ideas" ||
+		*thread.ParentID != "1082683372128055326" || *thread.OwnerID != "284102390608347136" || !*thread.NewlyCreated {
+		t.Fatalf("unexpected thread %+v", thread)
+	}
+	if thread.ThreadMetadata == nil || thread.ThreadMetadata.AutoArchiveDuration != 4320 || thread.ThreadMetadata.Archived ||
+		*thread.ThreadMetadata.CreateTimestamp != "2023-09-07T18:02:11.771000+00:00" {
+		t.Fatalf("unexpected thread metadata %+v", thread.ThreadMetadata)
+	}
+	if thread.Member == nil || *thread.Member.UserID != "284102390608347136" || thread.Member.Flags != 1 {
+		t.Fatalf("unexpected thread member %+v", thread.Member)
+	}
+}
+
+func TestThreadListSync(t *testing.T) {
+	var sync ThreadListSync
+	err := json.Unmarshal(readDispatch(t, ThreadListSyncType), &sync)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sync.GuildID != "1082683371452780544" || !reflect.DeepEqual(sync.ChannelIDs, []string{"1082683372128055326"}) {
+		t.Fatalf("unexpected sync %+v", sync)
+	}
+	if len(sync.Threads) != 1 || sync.Threads[0].ID != "1149433121431855195" || *sync.Threads[0].MessageCount != 3 {
+		t.Fatalf("unexpected threads %+v", sync.Threads)
+	}
+	if len(sync.Members) != 1 || *sync.Members[0].UserID != "1082680961921613945" {
+		t.Fatalf("unexpected members %+v", sync.Members)
+	}
+}
+
+func TestThreadMembersUpdate(t *testing.T) {
+	var update ThreadMembersUpdate
+	err := json.Unmarshal(readDispatch(t, ThreadMembersUpdateType), &update)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if update.ID != "1149433121431855195" || update.GuildID != "1082683371452780544" || update.MemberCount != 2 {
+		t.Fatalf("unexpected update %+v", update)
+	}
+	if !reflect.DeepEqual(update.RemovedMemberIDs, []string{"385214011553349632"}) {
+		t.Fatalf("got removed members %v", update.RemovedMemberIDs)
+	}
+	if len(update.AddedMembers) != 1 || update.AddedMembers[0].Member == nil ||
+		update.AddedMembers[0].Member.User.Username != "gopher-bot" || !*update.AddedMembers[0].Member.User.Bot {
+		t.Fatalf("unexpected added members %+v", update.AddedMembers)
+	}
+}
+
+func TestPresenceUpdate(t *testing.T) {
+	var presence PresenceUpdate
+	err := json.Unmarshal(readDispatch(t, PresenceUpdateType), &presence)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if presence.User.ID != "284102390608347136" || presence.GuildID != "1082683371452780544" || presence.Status != "dnd" {
+		t.Fatalf("unexpected presence %+v", presence)
+	}
+	if *presence.ClientStatus.Desktop != "dnd" || *presence.ClientStatus.Mobile != "idle" || presence.ClientStatus.Web != nil {
+		t.Fatalf("unexpected client status %+v", presence.ClientStatus)
+	}
+	if len(presence.Activities) != 1 {
+		t.Fatalf("got %d activities, want 1", len(presence.Activities))
+	}
+
+	activity := presence.Activities[0]
+	if activity.Name != "Spotify" || activity.Type != 2 || activity.CreatedAt != 1694109731906 ||
+		*activity.Details != "Goroutine Blues" || *activity.State != "Gopher Band" || activity.ApplicationID != nil {
+		t.Fatalf("unexpected activity %+v", activity)
+	}
+}
+
+func TestTypingStart(t *testing.T) {
+	var typing TypingStart
+	err := json.Unmarshal(readDispatch(t, TypingStartType), &typing)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if typing.UserID != "284102390608347136" || typing.ChannelID != "1082683372128055326" ||
+		*typing.GuildID != "1082683371452780544" || typing.Timestamp != 1694109745 {
+		t.Fatalf("unexpected typing %+v", typing)
+	}
+	if typing.Member == nil || *typing.Member.Nick != "sponge" {
+		t.Fatalf("unexpected member %+v", typing.Member)
+	}
+}
+
+func TestMessageUpdate(t *testing.T) {
+	var message Message
+	err := json.Unmarshal(readDispatch(t, MessageUpdateType), &message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if message.ID != "1149433838599483412" || message.ChannelID != "1082683372128055326" ||
+		*message.GuildID != "1082683371452780544" || *message.Content != "@gopher-bot play lofi/rain.ogg" {
+		t.Fatalf("unexpected message %+v", message)
+	}
+	if message.EditedTimestamp == nil || *message.EditedTimestamp != "2023-09-07T18:05:19.634000+00:00" {
+		t.Fatalf("got edited timestamp %v", message.EditedTimestamp)
+	}
+	if message.Author == nil || message.Author.ID != "284102390608347136" || message.Author.Bot != nil {
+		t.Fatalf("unexpected author %+v", message.Author)
+	}
+	if message.Member == nil || *message.Member.Nick != "sponge" || message.Member.User != nil {
+		t.Fatalf("unexpected member %+v", message.Member)
+	}
+}
+
+func TestGuildUpdate(t *testing.T) {
+	var guild Guild
+	err := json.Unmarshal(readDispatch(t, GuildUpdateType), &guild)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if guild.ID != "1082683371452780544" || guild.Name != "Gopher Radio" || guild.OwnerID != "284102390608347136" ||
+		guild.Description != "Music for gophers" || guild.AfkTimeout != 300 || *guild.SystemChannelID != "1082683372128055326" {
+		t.Fatalf("unexpected guild %+v", guild)
+	}
+	if !reflect.DeepEqual(guild.Features, []GuildFeature{"NEWS", "COMMUNITY"}) {
+		t.Fatalf("got features %v", guild.Features)
+	}
+	if len(guild.Roles) != 2 || guild.Roles[1].ID != "1082684120517718036" || guild.Roles[1].Name != "DJ" ||
+		guild.Roles[1].Color != 44504 || !guild.Roles[1].Hoist {
+		t.Fatalf("unexpected roles %+v", guild.Roles)
+	}
+	if len(guild.Emojis) != 1 || guild.Emojis[0].Name != "gopher" || !*guild.Emojis[0].Animated {
+		t.Fatalf("unexpected emojis %+v", guild.Emojis)
+	}
+	if guild.Unavailable != nil || guild.Channels != nil || guild.Members != nil {
+		t.Fatalf("expected no GUILD_CREATE only fields in %+v", guild)
+	}
+}
+
+func TestGuildDelete(t *testing.T) {
+	var guild UnavailableGuild
+	err := json.Unmarshal(readDispatch(t, GuildDeleteType), &guild)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if guild != (UnavailableGuild{ID: "1082683371452780544", Unavailable: true}) {
+		t.Fatalf("unexpected guild %+v", guild)
+	}
+}
+
+func TestGuildMemberAdd(t *testing.T) {
+	var member GuildMemberAdd
+	err := json.Unmarshal(readDispatch(t, GuildMemberAddType), &member)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if member.GuildID != "1082683371452780544" || member.User == nil || member.User.ID != "385214011553349632" ||
+		member.User.Username != "gopher-fan" || member.Nick != nil || !*member.Pending || len(member.Roles) != 0 {
+		t.Fatalf("unexpected member %+v", member)
+	}
+	if *member.JoinedAt != "2023-09-07T18:06:44.512000+00:00" {
+		t.Fatalf("got joined at %s", *member.JoinedAt)
+	}
+}
+
+func TestGuildMemberUpdate(t *testing.T) {
+	var member GuildMemberUpdate
+	err := json.Unmarshal(readDispatch(t, GuildMemberUpdateType), &member)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if member.GuildID != "1082683371452780544" || member.User.ID != "385214011553349632" || *member.Nick != "fan" ||
+		*member.Pending || !reflect.DeepEqual(member.Roles, []string{"1082684120517718036"}) {
+		t.Fatalf("unexpected member %+v", member)
+	}
+	if *member.CommunicationDisabledUntil != "2023-09-07T18:16:44.512000+00:00" {
+		t.Fatalf("got communication disabled until %s", *member.CommunicationDisabledUntil)
+	}
+}
+
+func TestGuildMemberRemove(t *testing.T) {
+	var member GuildMemberRemove
+	err := json.Unmarshal(readDispatch(t, GuildMemberRemoveType), &member)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if member.GuildID != "1082683371452780544" || member.User.ID != "385214011553349632" || member.User.Username != "gopher-fan" {
+		t.Fatalf("unexpected member %+v", member)
+	}
+}
+
+func TestChannelCreate(t *testing.T) {
+	var channel Channel
+	err := json.Unmarshal(readDispatch(t, ChannelCreateType), &channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if channel.ID != "1149434968155168828" || channel.Type != int(GuildVoice) || *channel.Name != "Listening Room" ||
+		*channel.GuildID != "1082683371452780544" || *channel.Bitrate != 96000 || *channel.UserLimit != 10 ||
+		channel.RTCRegion != nil || *channel.ParentID != "1082683372128055325" {
+		t.Fatalf("unexpected channel %+v", channel)
+	}
+
+	want := []PermissionOverwrite{{ID: "1082684120517718036", Type: 0, Allow: "3145728", Deny: "0"}}
+	if channel.PermissionOverwrites == nil || !reflect.DeepEqual(*channel.PermissionOverwrites, want) {
+		t.Fatalf("got permission overwrites %+v", channel.PermissionOverwrites)
+	}
+}
+
+func TestChannelUpdate(t *testing.T) {
+	var channel Channel
+	err := json.Unmarshal(readDispatch(t, ChannelUpdateType), &channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if channel.ID != "1082683372128055326" || channel.Type != int(GuildText) || *channel.Name != "music" ||
+		*channel.Topic != "Ask gopher-bot for a song" || *channel.RateLimitPerUser != 5 ||
+		*channel.LastMessageID != "1149433838599483412" || channel.Bitrate != nil {
+		t.Fatalf("unexpected channel %+v", channel)
+	}
+}
+
+func TestChannelDelete(t *testing.T) {
+	var channel Channel
+	err := json.Unmarshal(readDispatch(t, ChannelDeleteType), &channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if channel.ID != "1149434968155168828" || channel.Type != int(GuildVoice) || *channel.GuildID != "1082683371452780544" {
+		t.Fatalf("unexpected channel %+v", channel)
+	}
+}
+
+func TestThreadUpdate(t *testing.T) {
+	var thread Channel
+	err := json.Unmarshal(readDispatch(t, ThreadUpdateType), &thread)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if thread.ID != "1149433121431855195" || thread.Type != int(PublicThread) || *thread.MessageCount != 3 ||
+		*thread.MemberCount != 2 || thread.NewlyCreated != nil || thread.Member != nil {
+		t.Fatalf("unexpected thread %+v", thread)
+	}
+	if thread.ThreadMetadata == nil || !thread.ThreadMetadata.Archived || !thread.ThreadMetadata.Locked ||
+		thread.ThreadMetadata.AutoArchiveDuration != 1440 {
+		t.Fatalf("unexpected thread metadata %+v", thread.ThreadMetadata)
+	}
+}
+
+func TestThreadDelete(t *testing.T) {
+	var thread Channel
+	err := json.Unmarshal(readDispatch(t, ThreadDeleteType), &thread)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Channel{
+		ID:       "1149433121431855195",
+		Type:     int(PublicThread),
+		GuildID:  stringPtr("1082683371452780544"),
+		ParentID: stringPtr("1082683372128055326"),
+	}
+	if !reflect.DeepEqual(thread, want) {
+		t.Fatalf("got %+v, want %+v", thread, want)
+	}
+}
+
+func TestInteractionCreate(t *testing.T) {
+	var interaction Interaction
+	err := json.Unmarshal(readDispatch(t, InteractionCreateType), &interaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if interaction.ID != "1149435305884594278" || interaction.ApplicationID != "1082680961921613945" ||
+		interaction.Type != ApplicationCommandInteraction || interaction.Version != 1 ||
+		interaction.Token != "aW50ZXJhY3Rpb246MTE0OTQzNTMwNTg4NDU5NDI3ODpHb3BoZXJSYWRpbw" ||
+		*interaction.GuildID != "1082683371452780544" || *interaction.ChannelID != "1082683372128055326" ||
+		*interaction.AppPermissions != "1071698660929" || *interaction.Locale != "en-US" {
+		t.Fatalf("unexpected interaction %+v", interaction)
+	}
+	if invoker := interaction.Invoker(); invoker == nil || invoker.ID != "284102390608347136" || interaction.User != nil {
+		t.Fatalf("got invoker %+v, want the member's user", invoker)
+	}
+	if *interaction.Member.Permissions != "1071698660929" {
+		t.Fatalf("got member permissions %s", *interaction.Member.Permissions)
+	}
+
+	data := interaction.Data
+	if data == nil || data.ID != "1149071196767395860" || data.Name != "play" || data.Type != 1 || len(data.Options) != 1 {
+		t.Fatalf("unexpected data %+v", data)
+	}
+	if option := data.Options[0]; option.Name != "track" || option.Type != 3 || string(option.Value) != `"lofi/rain.ogg"` {
+		t.Fatalf("unexpected option %+v", option)
+	}
+}
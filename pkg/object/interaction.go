@@ -0,0 +1,104 @@
+package object
+
+import "encoding/json"
+
+type InteractionType int
+type InteractionCallbackType int
+
+const (
+	PingInteraction                           InteractionType = 1
+	ApplicationCommandInteraction             InteractionType = 2
+	MessageComponentInteraction               InteractionType = 3
+	ApplicationCommandAutocompleteInteraction InteractionType = 4
+	ModalSubmitInteraction                    InteractionType = 5
+
+	PongCallback                             InteractionCallbackType = 1
+	ChannelMessageWithSourceCallback         InteractionCallbackType = 4
+	DeferredChannelMessageWithSourceCallback InteractionCallbackType = 5
+	DeferredUpdateMessageCallback            InteractionCallbackType = 6
+	UpdateMessageCallback                    InteractionCallbackType = 7
+	ApplicationCommandAutocompleteCallback   InteractionCallbackType = 8
+	ModalCallback                            InteractionCallbackType = 9
+)
+
+type Interaction struct {
+	ID             string           `json:"id"`
+	ApplicationID  string           `json:"application_id"`
+	Type           InteractionType  `json:"type"`
+	Data           *InteractionData `json:"data,omitempty"`
+	GuildID        *string          `json:"guild_id,omitempty"`
+	ChannelID      *string          `json:"channel_id,omitempty"`
+	Member         *GuildMember     `json:"member,omitempty"`
+	User           *User            `json:"user,omitempty"`
+	Token          string           `json:"token"`
+	Version        int              `json:"version"`
+	Message        *Message         `json:"message,omitempty"`
+	AppPermissions *string          `json:"app_permissions,omitempty"`
+	Locale         *string          `json:"locale,omitempty"`
+	GuildLocale    *string          `json:"guild_locale,omitempty"`
+}
+
+// Invoker returns the user who triggered the interaction, Member.User in guilds and User in DMs.
+func (i *Interaction) Invoker() *User {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+
+	return i.User
+}
+
+type InteractionData struct {
+	ID            string                                    `json:"id,omitempty"`
+	Name          string                                    `json:"name,omitempty"`
+	Type          ApplicationCommandType                    `json:"type,omitempty"`
+	Resolved      *InteractionResolved                      `json:"resolved,omitempty"`
+	Options       []ApplicationCommandInteractionDataOption `json:"options,omitempty"`
+	GuildID       *string                                   `json:"guild_id,omitempty"`
+	TargetID      *string                                   `json:"target_id,omitempty"`
+	CustomID      string                                    `json:"custom_id,omitempty"`
+	ComponentType ComponentType                             `json:"component_type,omitempty"`
+	Values        []string                                  `json:"values,omitempty"`
+	Components    []Component                               `json:"components,omitempty"`
+}
+
+// ApplicationCommandInteractionDataOption is an option filled in by the user. Value holds the raw JSON value,
+// subcommands and subcommand groups have Options instead.
+type ApplicationCommandInteractionDataOption struct {
+	Name    string                                    `json:"name"`
+	Type    ApplicationCommandOptionType              `json:"type"`
+	Value   json.RawMessage                           `json:"value,omitempty"`
+	Options []ApplicationCommandInteractionDataOption `json:"options,omitempty"`
+	Focused bool                                      `json:"focused,omitempty"`
+}
+
+// InteractionResolved maps the IDs used as option values to the entities they refer to.
+type InteractionResolved struct {
+	Users       map[string]User        `json:"users,omitempty"`
+	Members     map[string]GuildMember `json:"members,omitempty"`
+	Roles       map[string]Role        `json:"roles,omitempty"`
+	Channels    map[string]Channel     `json:"channels,omitempty"`
+	Messages    map[string]Message     `json:"messages,omitempty"`
+	Attachments map[string]Attachment  `json:"attachments,omitempty"`
+}
+
+type InteractionResponse struct {
+	Type InteractionCallbackType  `json:"type"`
+	Data *InteractionCallbackData `json:"data,omitempty"`
+}
+
+// InteractionCallbackData holds the data of every callback type, only the fields relevant to the type are sent:
+// the message fields for messages, Choices for autocomplete results and CustomID and Title for modals.
+type InteractionCallbackData struct {
+	TTS             bool             `json:"tts,omitempty"`
+	Content         string           `json:"content,omitempty"`
+	Embeds          []Embed          `json:"embeds,omitempty"`
+	AllowedMentions *AllowedMentions `json:"allowed_mentions,omitempty"`
+	Flags           int              `json:"flags,omitempty"`
+	Attachments     []Attachment     `json:"attachments,omitempty"`
+	Components      []Component      `json:"components,omitempty"`
+
+	Choices *[]ApplicationCommandOptionChoice `json:"choices,omitempty"`
+
+	CustomID string `json:"custom_id,omitempty"`
+	Title    string `json:"title,omitempty"`
+}
@@ -5,6 +5,7 @@ import "nhooyr.io/websocket"
 type GuildFeature string
 type Dispatch string
 type ChannelType int
+type AllowedMentionType string
 
 const (
 	AnimatedBanner                        GuildFeature = "ANIMATED_BANNER"
@@ -39,13 +40,54 @@ const (
 	MessageCreateType     Dispatch = "MESSAGE_CREATE"
 	VoiceStateUpdateType  Dispatch = "VOICE_STATE_UPDATE"
 	VoiceServerUpdateType Dispatch = "VOICE_SERVER_UPDATE"
+	GuildUpdateType       Dispatch = "GUILD_UPDATE"
+	GuildDeleteType       Dispatch = "GUILD_DELETE"
+	ChannelCreateType     Dispatch = "CHANNEL_CREATE"
+	ChannelUpdateType     Dispatch = "CHANNEL_UPDATE"
+	ChannelDeleteType     Dispatch = "CHANNEL_DELETE"
+	GuildMemberAddType    Dispatch = "GUILD_MEMBER_ADD"
+	GuildMemberUpdateType Dispatch = "GUILD_MEMBER_UPDATE"
+	GuildMemberRemoveType Dispatch = "GUILD_MEMBER_REMOVE"
+	GuildRoleCreateType   Dispatch = "GUILD_ROLE_CREATE"
+	GuildRoleUpdateType   Dispatch = "GUILD_ROLE_UPDATE"
+	GuildRoleDeleteType   Dispatch = "GUILD_ROLE_DELETE"
 
-	GuildText         ChannelType = 0
-	DM                ChannelType = 1
-	GuildVoice        ChannelType = 2
-	GroupDM           ChannelType = 3
-	GuildCategory     ChannelType = 4
-	GuildAccouncement ChannelType = 5
+	MessageUpdateType         Dispatch = "MESSAGE_UPDATE"
+	MessageDeleteType         Dispatch = "MESSAGE_DELETE"
+	MessageDeleteBulkType     Dispatch = "MESSAGE_DELETE_BULK"
+	MessageReactionAddType    Dispatch = "MESSAGE_REACTION_ADD"
+	MessageReactionRemoveType Dispatch = "MESSAGE_REACTION_REMOVE"
+	ThreadCreateType          Dispatch = "THREAD_CREATE"
+	ThreadUpdateType          Dispatch = "THREAD_UPDATE"
+	ThreadDeleteType          Dispatch = "THREAD_DELETE"
+	ThreadListSyncType        Dispatch = "THREAD_LIST_SYNC"
+	ThreadMemberUpdateType    Dispatch = "THREAD_MEMBER_UPDATE"
+	ThreadMembersUpdateType   Dispatch = "THREAD_MEMBERS_UPDATE"
+	PresenceUpdateType        Dispatch = "PRESENCE_UPDATE"
+	TypingStartType           Dispatch = "TYPING_START"
+	InteractionCreateType     Dispatch = "INTERACTION_CREATE"
+	ResumedType               Dispatch = "RESUMED"
+
+	RoleMentions     AllowedMentionType = "roles"
+	UserMentions     AllowedMentionType = "users"
+	EveryoneMentions AllowedMentionType = "everyone"
+
+	SuppressEmbedsMessageFlag        int = 1 << 2
+	EphemeralMessageFlag             int = 1 << 6
+	SuppressNotificationsMessageFlag int = 1 << 12
+
+	GuildText          ChannelType = 0
+	DM                 ChannelType = 1
+	GuildVoice         ChannelType = 2
+	GroupDM            ChannelType = 3
+	GuildCategory      ChannelType = 4
+	GuildAccouncement  ChannelType = 5
+	AnnouncementThread ChannelType = 10
+	PublicThread       ChannelType = 11
+	PrivateThread      ChannelType = 12
+	GuildStageVoice    ChannelType = 13
+	GuildDirectory     ChannelType = 14
+	GuildForum         ChannelType = 15
 
 	UnknownError         int = 4000
 	UnknownOpcode        int = 4001
@@ -89,6 +131,24 @@ type Event[T any] struct {
 	T  *string `json:"t,omitempty"`
 }
 
+type GatewayBot struct {
+	URL               string            `json:"url"`
+	Shards            int               `json:"shards"`
+	SessionStartLimit SessionStartLimit `json:"session_start_limit"`
+}
+
+// SessionStartLimit tells how many more sessions can be started, ResetAfter is in milliseconds.
+type SessionStartLimit struct {
+	Total          int `json:"total"`
+	Remaining      int `json:"remaining"`
+	ResetAfter     int `json:"reset_after"`
+	MaxConcurrency int `json:"max_concurrency"`
+}
+
+type Hello struct {
+	HeartbeatInterval int `json:"heartbeat_interval"`
+}
+
 type Resume struct {
 	Token     string `json:"token"`
 	SessionID string `json:"session_id"`
@@ -100,6 +160,8 @@ type Identify struct {
 	Properties map[string]any `json:"properties"`
 	Compress   bool           `json:"compress"`
 	Intents    int            `json:"intents"`
+	// Shard is [shard_id, num_shards].
+	Shard *[2]int `json:"shard,omitempty"`
 }
 
 type VoiceIdentify struct {
@@ -109,6 +171,13 @@ type VoiceIdentify struct {
 	Token     string `json:"token"`
 }
 
+type VoiceStateUpdate struct {
+	GuildID   string  `json:"guild_id"`
+	ChannelID *string `json:"channel_id"`
+	SelfMute  bool    `json:"self_mute"`
+	SelfDeaf  bool    `json:"self_deaf"`
+}
+
 type VoiceState struct {
 	GuildID   *string `json:"guild_id,omitempty"`
 	ChannelID *string `json:"channel_id,omitempty"`
@@ -119,15 +188,56 @@ type VoiceState struct {
 }
 
 type Message struct {
-	ID              string  `json:"id"`
-	ChannelID       string  `json:"channel_id"`
-	Author          *User   `json:"author,omitempty"`
-	Content         *string `json:"content,omitempty"`
-	Timestamp       string  `json:"timestamp"`
-	EditedTimestamp *string `json:"edited_timestamp,omitempty"`
-	TTS             bool    `json:"tts"`
-	MentionEveryone bool    `json:"mention_everyone"`
-	Mentions        []User  `json:"mentions"`
+	ID                string            `json:"id"`
+	ChannelID         string            `json:"channel_id"`
+	GuildID           *string           `json:"guild_id,omitempty"`
+	Author            *User             `json:"author,omitempty"`
+	Member            *GuildMember      `json:"member,omitempty"`
+	Content           *string           `json:"content,omitempty"`
+	Timestamp         string            `json:"timestamp"`
+	EditedTimestamp   *string           `json:"edited_timestamp,omitempty"`
+	TTS               bool              `json:"tts"`
+	MentionEveryone   bool              `json:"mention_everyone"`
+	Mentions          []User            `json:"mentions"`
+	Embeds            []Embed           `json:"embeds"`
+	Attachments       []Attachment      `json:"attachments"`
+	MentionRoles      []string          `json:"mention_roles"`
+	Pinned            bool              `json:"pinned"`
+	WebhookID         *string           `json:"webhook_id,omitempty"`
+	Type              int               `json:"type"`
+	Flags             *int              `json:"flags,omitempty"`
+	MessageReference  *MessageReference `json:"message_reference,omitempty"`
+	ReferencedMessage *Message          `json:"referenced_message,omitempty"`
+	Components        []Component       `json:"components,omitempty"`
+}
+
+// Attachment describes a file attached to a message. When uploading files only ID (the index
+// of the uploaded file), Filename and Description are sent.
+type Attachment struct {
+	ID          string  `json:"id"`
+	Filename    string  `json:"filename"`
+	Description *string `json:"description,omitempty"`
+	ContentType *string `json:"content_type,omitempty"`
+	Size        int     `json:"size,omitempty"`
+	URL         string  `json:"url,omitempty"`
+	ProxyURL    string  `json:"proxy_url,omitempty"`
+	Height      *int    `json:"height,omitempty"`
+	Width       *int    `json:"width,omitempty"`
+	Ephemeral   *bool   `json:"ephemeral,omitempty"`
+}
+
+type MessageReference struct {
+	MessageID       *string `json:"message_id,omitempty"`
+	ChannelID       *string `json:"channel_id,omitempty"`
+	GuildID         *string `json:"guild_id,omitempty"`
+	FailIfNotExists *bool   `json:"fail_if_not_exists,omitempty"`
+}
+
+type AllowedMentions struct {
+	Parse       []AllowedMentionType `json:"parse"`
+	Roles       []string             `json:"roles,omitempty"`
+	Users       []string             `json:"users,omitempty"`
+	RepliedUser bool                 `json:"replied_user,omitempty"`
 }
 
 type Ready struct {
@@ -146,6 +256,39 @@ type VoiceServerUpdate struct {
 	Endpoint string `json:"endpoint"`
 }
 
+type VoiceHello struct {
+	HeartbeatInterval float64 `json:"heartbeat_interval"`
+}
+
+type VoiceReady struct {
+	SSRC  uint32   `json:"ssrc"`
+	IP    string   `json:"ip"`
+	Port  int      `json:"port"`
+	Modes []string `json:"modes"`
+}
+
+type VoiceSelectProtocol struct {
+	Protocol string                  `json:"protocol"`
+	Data     VoiceSelectProtocolData `json:"data"`
+}
+
+type VoiceSelectProtocolData struct {
+	Address string `json:"address"`
+	Port    int    `json:"port"`
+	Mode    string `json:"mode"`
+}
+
+type VoiceSpeaking struct {
+	Speaking int    `json:"speaking"`
+	Delay    int    `json:"delay"`
+	SSRC     uint32 `json:"ssrc"`
+}
+
+type VoiceSessionDescription struct {
+	Mode      string   `json:"mode"`
+	SecretKey [32]byte `json:"secret_key"`
+}
+
 type Application struct {
 	ID                             string         `json:"id"`
 	Name                           string         `json:"name"`
@@ -190,6 +333,36 @@ type Member struct {
 	User            User   `json:"user"`
 }
 
+type GuildMember struct {
+	User                       *User    `json:"user,omitempty"`
+	Nick                       *string  `json:"nick,omitempty"`
+	Avatar                     *string  `json:"avatar,omitempty"`
+	Roles                      []string `json:"roles"`
+	JoinedAt                   *string  `json:"joined_at"`
+	PremiumSince               *string  `json:"premium_since,omitempty"`
+	Deaf                       bool     `json:"deaf"`
+	Mute                       bool     `json:"mute"`
+	Flags                      int      `json:"flags"`
+	Pending                    *bool    `json:"pending,omitempty"`
+	Permissions                *string  `json:"permissions,omitempty"`
+	CommunicationDisabledUntil *string  `json:"communication_disabled_until,omitempty"`
+}
+
+type GuildMemberAdd struct {
+	GuildMember
+	GuildID string `json:"guild_id"`
+}
+
+type GuildMemberUpdate struct {
+	GuildMember
+	GuildID string `json:"guild_id"`
+}
+
+type GuildMemberRemove struct {
+	GuildID string `json:"guild_id"`
+	User    User   `json:"user"`
+}
+
 type User struct {
 	ID            string  `json:"id"`
 	Username      string  `json:"username"`
@@ -226,10 +399,10 @@ type Guild struct {
 	VerificationLevel           int            `json:"verification_level"`
 	DefaultMessageNotifications int            `json:"default_message_notifications"`
 	ExplicitContentFilter       int            `json:"explicit_content_filter"`
-	Members                     *[]Member      `json:"members,omitempty"`
+	Members                     *[]GuildMember `json:"members,omitempty"`
 	Channels                    *[]Channel     `json:"channels,omitempty"`
 	Roles                       []Role         `json:"roles"`
-	Emojis                      []Emoji        `json:"emoji"`
+	Emojis                      []Emoji        `json:"emojis"`
 	Features                    []GuildFeature `json:"features"`
 	MFALevel                    int            `json:"mfa_level"`
 	ApplicationID               *string        `json:"application_id"`
@@ -252,16 +425,43 @@ type Guild struct {
 	Stickers                    *[]Sticker     `json:"stickers,omitempty"`
 	PremiumProgressBarEnabled   bool           `json:"premium_progress_bar_enabled"`
 	VoiceStates                 *[]VoiceState  `json:"voice_states,omitempty"`
+	Unavailable                 *bool          `json:"unavailable,omitempty"`
+}
+
+type UnavailableGuild struct {
+	ID          string `json:"id"`
+	Unavailable bool   `json:"unavailable"`
 }
 
 type Channel struct {
-	ID        string  `json:"id"`
-	Type      int     `json:"type"`
-	GuildID   *string `json:"guild_id,omitempty"`
-	Position  *int    `json:"position,omitempty"`
-	Name      *string `json:"name,omitempty"`
-	Bitrate   *int    `json:"bitrate,omitempty"`
-	RTCRegion *string `json:"rtc_region,omitempty"`
+	ID                   string                 `json:"id"`
+	Type                 int                    `json:"type"`
+	GuildID              *string                `json:"guild_id,omitempty"`
+	Position             *int                   `json:"position,omitempty"`
+	PermissionOverwrites *[]PermissionOverwrite `json:"permission_overwrites,omitempty"`
+	Name                 *string                `json:"name,omitempty"`
+	Topic                *string                `json:"topic,omitempty"`
+	NSFW                 *bool                  `json:"nsfw,omitempty"`
+	LastMessageID        *string                `json:"last_message_id,omitempty"`
+	Bitrate              *int                   `json:"bitrate,omitempty"`
+	UserLimit            *int                   `json:"user_limit,omitempty"`
+	RateLimitPerUser     *int                   `json:"rate_limit_per_user,omitempty"`
+	OwnerID              *string                `json:"owner_id,omitempty"`
+	ParentID             *string                `json:"parent_id,omitempty"`
+	RTCRegion            *string                `json:"rtc_region,omitempty"`
+	MessageCount         *int                   `json:"message_count,omitempty"`
+	MemberCount          *int                   `json:"member_count,omitempty"`
+	ThreadMetadata       *ThreadMetadata        `json:"thread_metadata,omitempty"`
+	Member               *ThreadMember          `json:"member,omitempty"`
+	Flags                *int                   `json:"flags,omitempty"`
+	NewlyCreated         *bool                  `json:"newly_created,omitempty"`
+}
+
+type PermissionOverwrite struct {
+	ID    string `json:"id"`
+	Type  int    `json:"type"`
+	Allow string `json:"allow"`
+	Deny  string `json:"deny"`
 }
 
 type WelcomeScreen struct {
@@ -283,7 +483,7 @@ type Emoji struct {
 	User          *User   `json:"user,omitempty"`
 	RequireColons *bool   `json:"require_colons,omitempty"`
 	Managed       *bool   `json:"managed,omitempty"`
-	Animated      *bool   `json:"aminated,omitempty"`
+	Animated      *bool   `json:"animated,omitempty"`
 	Available     *bool   `json:"available,omitempty"`
 }
 
@@ -316,6 +516,16 @@ type Role struct {
 	Tags         *RoleTags `json:"tags,omitempty"`
 }
 
+type GuildRole struct {
+	GuildID string `json:"guild_id"`
+	Role    Role   `json:"role"`
+}
+
+type GuildRoleDelete struct {
+	GuildID string `json:"guild_id"`
+	RoleID  string `json:"role_id"`
+}
+
 type RoleTags struct {
 	BotID                 *string `json:"bot_id,omitempty"`
 	IntegrationID         *string `json:"integration_id,omitempty"`
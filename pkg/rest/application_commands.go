@@ -0,0 +1,74 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/bsponge/discordGopher/pkg/object"
+)
+
+// All application command methods manage guild commands when guildID is set and global commands otherwise.
+
+func commandsPath(applicationID string, guildID string) string {
+	if guildID == "" {
+		return fmt.Sprintf("/applications/%s/commands", applicationID)
+	}
+
+	return fmt.Sprintf("/applications/%s/guilds/%s/commands", applicationID, guildID)
+}
+
+func (c *Client) GetApplicationCommands(ctx context.Context, applicationID string, guildID string) ([]object.ApplicationCommand, error) {
+	var commands []object.ApplicationCommand
+	err := c.Do(ctx, http.MethodGet, commandsPath(applicationID, guildID)+"?with_localizations=true", nil, &commands)
+	if err != nil {
+		return nil, err
+	}
+
+	return commands, nil
+}
+
+func (c *Client) CreateApplicationCommand(ctx context.Context, applicationID string, guildID string, command *object.ApplicationCommand) (*object.ApplicationCommand, error) {
+	var created object.ApplicationCommand
+	err := c.Do(ctx, http.MethodPost, commandsPath(applicationID, guildID), command, &created)
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (c *Client) EditApplicationCommand(ctx context.Context, applicationID string, guildID string, commandID string, command *object.ApplicationCommand) (*object.ApplicationCommand, error) {
+	var edited object.ApplicationCommand
+	err := c.Do(ctx, http.MethodPatch, commandsPath(applicationID, guildID)+"/"+commandID, command, &edited)
+	if err != nil {
+		return nil, err
+	}
+
+	return &edited, nil
+}
+
+func (c *Client) DeleteApplicationCommand(ctx context.Context, applicationID string, guildID string, commandID string) error {
+	return c.Do(ctx, http.MethodDelete, commandsPath(applicationID, guildID)+"/"+commandID, nil, nil)
+}
+
+// BulkOverwriteApplicationCommands replaces all the commands with the given ones.
+func (c *Client) BulkOverwriteApplicationCommands(ctx context.Context, applicationID string, guildID string, commands []object.ApplicationCommand) ([]object.ApplicationCommand, error) {
+	var overwritten []object.ApplicationCommand
+	err := c.Do(ctx, http.MethodPut, commandsPath(applicationID, guildID), commands, &overwritten)
+	if err != nil {
+		return nil, err
+	}
+
+	return overwritten, nil
+}
+
+func (c *Client) GetGuildApplicationCommandPermissions(ctx context.Context, applicationID string, guildID string) ([]object.GuildApplicationCommandPermissions, error) {
+	var permissions []object.GuildApplicationCommandPermissions
+	err := c.Do(ctx, http.MethodGet, fmt.Sprintf("/applications/%s/guilds/%s/commands/permissions", applicationID, guildID), nil, &permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
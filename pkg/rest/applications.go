@@ -0,0 +1,19 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bsponge/discordGopher/pkg/object"
+)
+
+// GetCurrentApplication returns the application of the bot.
+func (c *Client) GetCurrentApplication(ctx context.Context) (*object.Application, error) {
+	var application object.Application
+	err := c.Do(ctx, http.MethodGet, "/applications/@me", nil, &application)
+	if err != nil {
+		return nil, err
+	}
+
+	return &application, nil
+}
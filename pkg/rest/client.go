@@ -0,0 +1,175 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bsponge/discordGopher/pkg/log"
+)
+
+const (
+	DefaultBaseURL = "https://discord.com/api/v10"
+
+	userAgent  = "DiscordBot (https://github.com/bsponge/discordGopher, 1.0)"
+	maxRetries = 3
+)
+
+// Client is an HTTP client for the Discord REST API which keeps track of the rate limits
+// of all the routes it calls. A single Client should be shared by everything that talks to the API.
+type Client struct {
+	token      string
+	baseURL    string
+	httpClient *http.Client
+
+	limiter *rateLimiter
+}
+
+type Option func(*Client)
+
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+func NewClient(token string, opts ...Option) *Client {
+	client := &Client{
+		token:      token,
+		baseURL:    DefaultBaseURL,
+		httpClient: http.DefaultClient,
+		limiter:    newRateLimiter(),
+	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	return client
+}
+
+type request struct {
+	method string
+	path   string
+	header http.Header
+
+	// body returns a fresh request body together with its content type, it is called once per attempt.
+	body func() (io.Reader, string, error)
+	// oneShot marks requests whose body is streamed and thus cannot be retried.
+	oneShot bool
+}
+
+// Do sends a request with an optional JSON body to the path relative to the base URL
+// and unmarshals the JSON response into out unless it is nil.
+func (c *Client) Do(ctx context.Context, method string, path string, body any, out any) error {
+	req := &request{
+		method: method,
+		path:   path,
+	}
+
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+
+		req.body = func() (io.Reader, string, error) {
+			return bytes.NewReader(payload), "application/json", nil
+		}
+	}
+
+	return c.do(ctx, req, out)
+}
+
+func (c *Client) do(ctx context.Context, req *request, out any) error {
+	bucket := c.limiter.bucket(req.method, req.path)
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, bucket, req)
+		if err != nil {
+			return err
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			retryAfter := c.limiter.handleTooManyRequests(bucket, resp.Header, body)
+			if req.oneShot || attempt >= maxRetries {
+				return newAPIError(req.method, req.path, resp.StatusCode, body)
+			}
+
+			log.Logger().WithField("path", req.path).Warnf("Rate limited, retrying in %s", retryAfter)
+			continue
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return newAPIError(req.method, req.path, resp.StatusCode, body)
+		}
+
+		if out == nil || resp.StatusCode == http.StatusNoContent {
+			return nil
+		}
+
+		return json.Unmarshal(body, out)
+	}
+}
+
+// send waits until the rate limits allow the request and sends it. The bucket stays locked
+// until the response headers are processed, so requests to a single bucket are sent one by one.
+func (c *Client) send(ctx context.Context, bucket *bucket, req *request) (*http.Response, error) {
+	bucket.mtx.Lock()
+	defer bucket.mtx.Unlock()
+
+	err := c.limiter.wait(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	var contentType string
+	if req.body != nil {
+		body, contentType, err = req.body()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, c.baseURL+req.path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range req.header {
+		httpReq.Header[key] = values
+	}
+
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bot %s", c.token))
+	httpReq.Header.Set("User-Agent", userAgent)
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	c.limiter.update(bucket, req.method, req.path, resp.Header, time.Now())
+
+	return resp, nil
+}
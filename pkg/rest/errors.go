@@ -0,0 +1,155 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// JSON error codes returned by the Discord API.
+const (
+	UnknownChannelCode     = 10003
+	UnknownGuildCode       = 10004
+	UnknownMessageCode     = 10008
+	UnknownInteractionCode = 10062
+	MissingAccessCode      = 50001
+	MissingPermissionsCode = 50013
+	InvalidFormBodyCode    = 50035
+)
+
+// APIError describes a request rejected by the Discord API.
+type APIError struct {
+	StatusCode int
+	Method     string
+	Path       string
+
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Errors  json.RawMessage `json:"errors,omitempty"`
+}
+
+type FieldError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func newAPIError(method string, path string, statusCode int, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: statusCode,
+		Method:     method,
+		Path:       path,
+	}
+
+	err := json.Unmarshal(body, apiErr)
+	if err != nil || apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(body))
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(statusCode)
+		}
+	}
+
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("discord api responded to %s %s with status %d", e.Method, e.Path, e.StatusCode)
+	if e.Code != 0 {
+		msg = fmt.Sprintf("%s, code %d", msg, e.Code)
+	}
+
+	msg = fmt.Sprintf("%s: %s", msg, e.Message)
+
+	fieldErrors := e.FieldErrors()
+	if len(fieldErrors) == 0 {
+		return msg
+	}
+
+	fields := make([]string, 0, len(fieldErrors))
+	for field := range fieldErrors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	details := make([]string, 0, len(fields))
+	for _, field := range fields {
+		for _, fieldError := range fieldErrors[field] {
+			details = append(details, fmt.Sprintf("%s: %s", field, fieldError.Message))
+		}
+	}
+
+	return fmt.Sprintf("%s (%s)", msg, strings.Join(details, "; "))
+}
+
+// FieldErrors flattens the nested errors tree of the response into a map keyed by the path
+// of the invalid field, e.g. "embeds.0.fields.1.name".
+func (e *APIError) FieldErrors() map[string][]FieldError {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+
+	var tree map[string]json.RawMessage
+	err := json.Unmarshal(e.Errors, &tree)
+	if err != nil {
+		return nil
+	}
+
+	fieldErrors := make(map[string][]FieldError)
+	collectFieldErrors("", tree, fieldErrors)
+
+	return fieldErrors
+}
+
+func collectFieldErrors(path string, tree map[string]json.RawMessage, fieldErrors map[string][]FieldError) {
+	for key, value := range tree {
+		if key == "_errors" {
+			var errs []FieldError
+			if json.Unmarshal(value, &errs) == nil {
+				fieldErrors[path] = append(fieldErrors[path], errs...)
+			}
+
+			continue
+		}
+
+		var subtree map[string]json.RawMessage
+		if json.Unmarshal(value, &subtree) != nil {
+			continue
+		}
+
+		subpath := key
+		if path != "" {
+			subpath = path + "." + key
+		}
+
+		collectFieldErrors(subpath, subtree, fieldErrors)
+	}
+}
+
+// AsAPIError returns the APIError wrapped by err, if there is one.
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	ok := errors.As(err, &apiErr)
+	return apiErr, ok
+}
+
+func IsNotFound(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+func IsUnauthorized(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.StatusCode == http.StatusUnauthorized
+}
+
+func IsMissingPermissions(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && (apiErr.Code == MissingPermissionsCode || apiErr.Code == MissingAccessCode)
+}
+
+func IsRateLimited(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.StatusCode == http.StatusTooManyRequests
+}
@@ -0,0 +1,19 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bsponge/discordGopher/pkg/object"
+)
+
+// GetGatewayBot returns the gateway URL the bot should connect to.
+func (c *Client) GetGatewayBot(ctx context.Context) (*object.GatewayBot, error) {
+	var gateway object.GatewayBot
+	err := c.Do(ctx, http.MethodGet, "/gateway/bot", nil, &gateway)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gateway, nil
+}
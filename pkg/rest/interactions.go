@@ -0,0 +1,64 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/bsponge/discordGopher/pkg/object"
+)
+
+const originalMessageID = "@original"
+
+// CreateInteractionResponse answers the interaction, which has to happen within 3 seconds of receiving it.
+func (c *Client) CreateInteractionResponse(ctx context.Context, interactionID string, token string, response *object.InteractionResponse) error {
+	return c.Do(ctx, http.MethodPost, fmt.Sprintf("/interactions/%s/%s/callback", interactionID, token), response, nil)
+}
+
+// The interaction token stays valid for 15 minutes, the methods below use it to manage the messages of the response.
+
+func interactionMessagePath(applicationID string, token string, messageID string) string {
+	return fmt.Sprintf("/webhooks/%s/%s/messages/%s", applicationID, token, messageID)
+}
+
+func (c *Client) GetOriginalInteractionResponse(ctx context.Context, applicationID string, token string) (*object.Message, error) {
+	var message object.Message
+	err := c.Do(ctx, http.MethodGet, interactionMessagePath(applicationID, token, originalMessageID), nil, &message)
+	if err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+func (c *Client) EditOriginalInteractionResponse(ctx context.Context, applicationID string, token string, params *MessageEditParams) (*object.Message, error) {
+	return c.editMessage(ctx, interactionMessagePath(applicationID, token, originalMessageID), params)
+}
+
+func (c *Client) DeleteOriginalInteractionResponse(ctx context.Context, applicationID string, token string) error {
+	return c.Do(ctx, http.MethodDelete, interactionMessagePath(applicationID, token, originalMessageID), nil, nil)
+}
+
+// CreateFollowupMessage sends another message in response to the interaction. Set Flags to object.EphemeralMessageFlag
+// to make it visible only to the invoking user.
+func (c *Client) CreateFollowupMessage(ctx context.Context, applicationID string, token string, params *MessageCreateParams) (*object.Message, error) {
+	return c.createMessage(ctx, fmt.Sprintf("/webhooks/%s/%s", applicationID, token), params)
+}
+
+func (c *Client) GetFollowupMessage(ctx context.Context, applicationID string, token string, messageID string) (*object.Message, error) {
+	var message object.Message
+	err := c.Do(ctx, http.MethodGet, interactionMessagePath(applicationID, token, messageID), nil, &message)
+	if err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+func (c *Client) EditFollowupMessage(ctx context.Context, applicationID string, token string, messageID string, params *MessageEditParams) (*object.Message, error) {
+	return c.editMessage(ctx, interactionMessagePath(applicationID, token, messageID), params)
+}
+
+func (c *Client) DeleteFollowupMessage(ctx context.Context, applicationID string, token string, messageID string) error {
+	return c.Do(ctx, http.MethodDelete, interactionMessagePath(applicationID, token, messageID), nil, nil)
+}
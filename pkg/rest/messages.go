@@ -0,0 +1,153 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/bsponge/discordGopher/pkg/object"
+)
+
+type MessageCreateParams struct {
+	Content          string                   `json:"content,omitempty"`
+	Nonce            string                   `json:"nonce,omitempty"`
+	TTS              bool                     `json:"tts,omitempty"`
+	Flags            int                      `json:"flags,omitempty"`
+	Embeds           []object.Embed           `json:"embeds,omitempty"`
+	AllowedMentions  *object.AllowedMentions  `json:"allowed_mentions,omitempty"`
+	MessageReference *object.MessageReference `json:"message_reference,omitempty"`
+	Attachments      []object.Attachment      `json:"attachments,omitempty"`
+	Components       []object.Component       `json:"components,omitempty"`
+
+	// Files are uploaded with the message, their attachment entries are added automatically.
+	Files []*File `json:"-"`
+}
+
+// MessageEditParams holds the fields to change, nil fields are left untouched.
+type MessageEditParams struct {
+	Content         *string                 `json:"content,omitempty"`
+	Embeds          *[]object.Embed         `json:"embeds,omitempty"`
+	Flags           *int                    `json:"flags,omitempty"`
+	AllowedMentions *object.AllowedMentions `json:"allowed_mentions,omitempty"`
+	// Attachments lists the attachments to keep, the ones missing from the list are removed.
+	Attachments *[]object.Attachment `json:"attachments,omitempty"`
+	Components  *[]object.Component  `json:"components,omitempty"`
+
+	// Files are uploaded and appended to the kept attachments.
+	Files []*File `json:"-"`
+}
+
+type GetMessagesParams struct {
+	Around string
+	Before string
+	After  string
+	Limit  int
+}
+
+func (c *Client) CreateMessage(ctx context.Context, channelID string, params *MessageCreateParams) (*object.Message, error) {
+	return c.createMessage(ctx, fmt.Sprintf("/channels/%s/messages", channelID), params)
+}
+
+func (c *Client) createMessage(ctx context.Context, path string, params *MessageCreateParams) (*object.Message, error) {
+	var message object.Message
+	var err error
+	if len(params.Files) > 0 {
+		payload := *params
+		payload.Attachments = append(attachments(params.Files), params.Attachments...)
+		err = c.doMultipart(ctx, http.MethodPost, path, &payload, params.Files, &message)
+	} else {
+		err = c.Do(ctx, http.MethodPost, path, params, &message)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// Reply sends a message in the channel of the given message, referencing it as a reply.
+func (c *Client) Reply(ctx context.Context, message *object.Message, params *MessageCreateParams) (*object.Message, error) {
+	reply := *params
+	failIfNotExists := false
+	reply.MessageReference = &object.MessageReference{
+		MessageID:       &message.ID,
+		ChannelID:       &message.ChannelID,
+		GuildID:         message.GuildID,
+		FailIfNotExists: &failIfNotExists,
+	}
+
+	return c.CreateMessage(ctx, message.ChannelID, &reply)
+}
+
+func (c *Client) EditMessage(ctx context.Context, channelID string, messageID string, params *MessageEditParams) (*object.Message, error) {
+	return c.editMessage(ctx, fmt.Sprintf("/channels/%s/messages/%s", channelID, messageID), params)
+}
+
+func (c *Client) editMessage(ctx context.Context, path string, params *MessageEditParams) (*object.Message, error) {
+	var message object.Message
+	var err error
+	if len(params.Files) > 0 {
+		payload := *params
+		var uploaded []object.Attachment
+		if params.Attachments != nil {
+			uploaded = append(uploaded, *params.Attachments...)
+		}
+		uploaded = append(uploaded, attachments(params.Files)...)
+		payload.Attachments = &uploaded
+		err = c.doMultipart(ctx, http.MethodPatch, path, &payload, params.Files, &message)
+	} else {
+		err = c.Do(ctx, http.MethodPatch, path, params, &message)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+func (c *Client) DeleteMessage(ctx context.Context, channelID string, messageID string) error {
+	return c.Do(ctx, http.MethodDelete, fmt.Sprintf("/channels/%s/messages/%s", channelID, messageID), nil, nil)
+}
+
+func (c *Client) GetMessage(ctx context.Context, channelID string, messageID string) (*object.Message, error) {
+	var message object.Message
+	err := c.Do(ctx, http.MethodGet, fmt.Sprintf("/channels/%s/messages/%s", channelID, messageID), nil, &message)
+	if err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+func (c *Client) GetMessages(ctx context.Context, channelID string, params *GetMessagesParams) ([]object.Message, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.Around != "" {
+			query.Set("around", params.Around)
+		}
+		if params.Before != "" {
+			query.Set("before", params.Before)
+		}
+		if params.After != "" {
+			query.Set("after", params.After)
+		}
+		if params.Limit > 0 {
+			query.Set("limit", strconv.Itoa(params.Limit))
+		}
+	}
+
+	path := fmt.Sprintf("/channels/%s/messages", channelID)
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var messages []object.Message
+	err := c.Do(ctx, http.MethodGet, path, nil, &messages)
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
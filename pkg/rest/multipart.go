@@ -0,0 +1,132 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"github.com/bsponge/discordGopher/pkg/object"
+)
+
+const spoilerPrefix = "SPOILER_"
+
+// File is a file uploaded together with a message. Its content is streamed from Reader
+// straight into the request, so it is never held in memory as a whole.
+type File struct {
+	Name        string
+	Description string
+	ContentType string
+	Spoiler     bool
+	Reader      io.Reader
+}
+
+func (f *File) filename() string {
+	if f.Spoiler && !strings.HasPrefix(f.Name, spoilerPrefix) {
+		return spoilerPrefix + f.Name
+	}
+
+	return f.Name
+}
+
+// attachments describes the uploaded files in the payload, the IDs refer to the files[n] form fields.
+func attachments(files []*File) []object.Attachment {
+	attachments := make([]object.Attachment, 0, len(files))
+	for i, file := range files {
+		attachment := object.Attachment{
+			ID:       strconv.Itoa(i),
+			Filename: file.filename(),
+		}
+
+		if file.Description != "" {
+			description := file.Description
+			attachment.Description = &description
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments
+}
+
+// doMultipart sends the payload as payload_json together with the files as a multipart/form-data request.
+func (c *Client) doMultipart(ctx context.Context, method string, path string, payload any, files []*File, out any) error {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req := &request{
+		method:  method,
+		path:    path,
+		oneShot: true,
+	}
+
+	var used bool
+	req.body = func() (io.Reader, string, error) {
+		if used {
+			return nil, "", fmt.Errorf("the files of %s %s have already been streamed", method, path)
+		}
+		used = true
+
+		pr, pw := io.Pipe()
+		mw := multipart.NewWriter(pw)
+
+		go func() {
+			pw.CloseWithError(writeMultipart(mw, payloadJSON, files))
+		}()
+
+		return pr, mw.FormDataContentType(), nil
+	}
+
+	return c.do(ctx, req, out)
+}
+
+func writeMultipart(mw *multipart.Writer, payloadJSON []byte, files []*File) error {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="payload_json"`)
+	header.Set("Content-Type", "application/json")
+
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = part.Write(payloadJSON)
+	if err != nil {
+		return err
+	}
+
+	for i, file := range files {
+		contentType := file.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="files[%d]"; filename="%s"`, i, escapeQuotes(file.filename())))
+		header.Set("Content-Type", contentType)
+
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(part, file.Reader)
+		if err != nil {
+			return fmt.Errorf("could not stream file %s: %w", file.Name, err)
+		}
+	}
+
+	return mw.Close()
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
@@ -0,0 +1,254 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	globalRequestsPerSecond = 50
+
+	// bucketSweepInterval is how often the buckets whose limit has reset are dropped. Webhook tokens are major
+	// parameters and every interaction has its own, so the buckets would pile up otherwise.
+	bucketSweepInterval = time.Minute
+
+	headerBucket     = "X-RateLimit-Bucket"
+	headerRemaining  = "X-RateLimit-Remaining"
+	headerResetAfter = "X-RateLimit-Reset-After"
+	headerGlobal     = "X-RateLimit-Global"
+	headerScope      = "X-RateLimit-Scope"
+	headerRetryAfter = "Retry-After"
+)
+
+var (
+	snowflakeRegex = regexp.MustCompile(`/\d{15,}`)
+	reactionRegex  = regexp.MustCompile(`/reactions/.*`)
+	majorRegex     = regexp.MustCompile(`^/(channels|guilds|webhooks|interactions)/(\d+)(/[^/]+)?`)
+	tokenRegex     = regexp.MustCompile(`^/(webhooks|interactions)/(\d+)/[^/]+`)
+)
+
+// bucket tracks a single Discord rate limit bucket. mtx is held for the whole duration
+// of a request, so requests sharing a bucket never race for its remaining budget.
+type bucket struct {
+	mtx sync.Mutex
+
+	remaining int
+	reset     time.Time
+}
+
+type rateLimiter struct {
+	mtx sync.Mutex
+
+	// routes maps route keys to the bucket hashes Discord reported for them.
+	routes  map[string]string
+	buckets map[string]*bucket
+
+	globalReset time.Time
+	windowStart time.Time
+	windowCount int
+	lastSweep   time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		routes:  make(map[string]string),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// routeKey identifies a route the way Discord does, keeping the major parameters (channel, guild, webhook and
+// interaction IDs and webhook tokens) and replacing all other IDs and tokens with placeholders. Keying callbacks by
+// their interaction keeps them from waiting for each other, they have to be acknowledged within 3 seconds.
+func routeKey(method string, path string) (string, string) {
+	path, _, _ = strings.Cut(path, "?")
+
+	var major string
+	if match := majorRegex.FindStringSubmatch(path); match != nil {
+		major = match[2]
+		if match[1] == "webhooks" && match[3] != "" {
+			// The webhook token is a major parameter as well.
+			major += match[3]
+		}
+	}
+
+	route := tokenRegex.ReplaceAllString(path, "/$1/$2/:token")
+	route = reactionRegex.ReplaceAllString(route, "/reactions/:reaction")
+	route = snowflakeRegex.ReplaceAllString(route, "/:id")
+
+	return method + " " + route, major
+}
+
+func (l *rateLimiter) bucket(method string, path string) *bucket {
+	route, major := routeKey(method, path)
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.sweep(time.Now())
+
+	key := route
+	if hash, ok := l.routes[route]; ok {
+		key = hash + ":" + major
+	} else if major != "" {
+		key = route + ":" + major
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{remaining: 1}
+		l.buckets[key] = b
+	}
+
+	return b
+}
+
+// sweep drops the buckets whose limit has reset, they would be created with the same state on the next request.
+// Buckets locked by a request in progress are kept. It has to be called with l.mtx held.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < bucketSweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if !b.mtx.TryLock() {
+			continue
+		}
+
+		if now.After(b.reset) {
+			delete(l.buckets, key)
+		}
+
+		b.mtx.Unlock()
+	}
+}
+
+// wait blocks until both the bucket and the global limit allow another request.
+func (l *rateLimiter) wait(ctx context.Context, b *bucket) error {
+	for {
+		now := time.Now()
+
+		var until time.Time
+		if b.remaining <= 0 && now.Before(b.reset) {
+			until = b.reset
+		}
+
+		l.mtx.Lock()
+		if now.Before(l.globalReset) && l.globalReset.After(until) {
+			until = l.globalReset
+		}
+
+		if until.IsZero() {
+			if now.Sub(l.windowStart) >= time.Second {
+				l.windowStart = now
+				l.windowCount = 0
+			}
+
+			if l.windowCount < globalRequestsPerSecond {
+				l.windowCount++
+				l.mtx.Unlock()
+
+				if b.remaining > 0 {
+					b.remaining--
+				}
+
+				return nil
+			}
+
+			until = l.windowStart.Add(time.Second)
+		}
+		l.mtx.Unlock()
+
+		err := sleep(ctx, until.Sub(now))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// update stores the rate limit state received in the response headers. It has to be called with the bucket locked.
+func (l *rateLimiter) update(b *bucket, method string, path string, header http.Header, now time.Time) {
+	if remaining, err := strconv.Atoi(header.Get(headerRemaining)); err == nil {
+		b.remaining = remaining
+	}
+
+	if resetAfter, err := strconv.ParseFloat(header.Get(headerResetAfter), 64); err == nil {
+		b.reset = now.Add(time.Duration(resetAfter * float64(time.Second)))
+	}
+
+	hash := header.Get(headerBucket)
+	if hash == "" {
+		return
+	}
+
+	route, major := routeKey(method, path)
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if l.routes[route] == hash {
+		return
+	}
+
+	l.routes[route] = hash
+
+	// Routes sharing a bucket hash share the bucket, the first one seen becomes the shared one.
+	key := hash + ":" + major
+	if _, ok := l.buckets[key]; !ok {
+		l.buckets[key] = b
+	}
+}
+
+type tooManyRequests struct {
+	RetryAfter float64 `json:"retry_after"`
+	Global     bool    `json:"global"`
+}
+
+// handleTooManyRequests applies the limit reported by a 429 response and returns how long the caller has to wait.
+// It has to be called with the bucket unlocked.
+func (l *rateLimiter) handleTooManyRequests(b *bucket, header http.Header, body []byte) time.Duration {
+	var payload tooManyRequests
+	_ = json.Unmarshal(body, &payload)
+
+	retryAfter := time.Duration(payload.RetryAfter * float64(time.Second))
+	if retryAfter == 0 {
+		if seconds, err := strconv.ParseFloat(header.Get(headerRetryAfter), 64); err == nil {
+			retryAfter = time.Duration(seconds * float64(time.Second))
+		}
+	}
+
+	reset := time.Now().Add(retryAfter)
+
+	if payload.Global || header.Get(headerGlobal) == "true" || header.Get(headerScope) == "global" {
+		l.mtx.Lock()
+		l.globalReset = reset
+		l.mtx.Unlock()
+
+		return retryAfter
+	}
+
+	b.mtx.Lock()
+	b.remaining = 0
+	b.reset = reset
+	b.mtx.Unlock()
+
+	return retryAfter
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
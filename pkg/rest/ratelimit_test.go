@@ -0,0 +1,82 @@
+package rest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRouteKey(t *testing.T) {
+	tests := []struct {
+		path  string
+		route string
+		major string
+	}{
+		{
+			path:  "/channels/1082683372128055326/messages/1149071652235489301",
+			route: "POST /channels/:id/messages/:id",
+			major: "1082683372128055326",
+		},
+		{
+			path:  "/interactions/1149071652235489301/aW50ZXJhY3Rpb246MTE0OTA3/callback",
+			route: "POST /interactions/:id/:token/callback",
+			major: "1149071652235489301",
+		},
+		{
+			path:  "/webhooks/1082680961921613945/aW50ZXJhY3Rpb246MTE0OTA3/messages/@original",
+			route: "POST /webhooks/:id/:token/messages/@original",
+			major: "1082680961921613945/aW50ZXJhY3Rpb246MTE0OTA3",
+		},
+	}
+
+	for _, test := range tests {
+		route, major := routeKey(http.MethodPost, test.path)
+		if route != test.route || major != test.major {
+			t.Fatalf("got route %q and major %q for %s, want %q and %q", route, major, test.path, test.route, test.major)
+		}
+	}
+}
+
+func TestInteractionCallbacksDoNotShareBucket(t *testing.T) {
+	l := newRateLimiter()
+
+	first := l.bucket(http.MethodPost, "/interactions/1149071652235489301/aW50ZXJhY3Rpb246MTE0OTA3/callback")
+	second := l.bucket(http.MethodPost, "/interactions/1149071652235489302/aW50ZXJhY3Rpb246MTE0OTA4/callback")
+	if first == second {
+		t.Fatal("expected callbacks of different interactions to have their own buckets")
+	}
+
+	// Discord reports the same bucket hash for every callback, the interaction still keeps them apart.
+	l.routes["POST /interactions/:id/:token/callback"] = "e1ce4e3b"
+	first = l.bucket(http.MethodPost, "/interactions/1149071652235489301/aW50ZXJhY3Rpb246MTE0OTA3/callback")
+	second = l.bucket(http.MethodPost, "/interactions/1149071652235489302/aW50ZXJhY3Rpb246MTE0OTA4/callback")
+	if first == second {
+		t.Fatal("expected callbacks of different interactions to have their own buckets after the hash is known")
+	}
+}
+
+func TestRateLimiterSweepsResetBuckets(t *testing.T) {
+	l := newRateLimiter()
+
+	limited := l.bucket(http.MethodPost, "/channels/1082683372128055326/messages")
+	limited.reset = time.Now().Add(time.Hour)
+
+	for _, token := range []string{"first", "second", "third"} {
+		l.bucket(http.MethodPost, "/webhooks/1082680961921613945/"+token)
+	}
+
+	// A request holds the lock of its bucket, so the bucket is kept.
+	inUse := l.bucket(http.MethodPost, "/webhooks/1082680961921613945/fourth")
+	inUse.mtx.Lock()
+
+	l.lastSweep = time.Time{}
+	l.sweep(time.Now())
+	inUse.mtx.Unlock()
+
+	if len(l.buckets) != 2 {
+		t.Fatalf("got %d buckets after the sweep, want 2", len(l.buckets))
+	}
+	if l.bucket(http.MethodPost, "/channels/1082683372128055326/messages") != limited {
+		t.Fatal("expected the bucket which has not reset yet to be kept")
+	}
+}
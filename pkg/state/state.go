@@ -0,0 +1,460 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/bsponge/discordGopher/pkg/object"
+)
+
+type CacheFlags int
+
+const (
+	CacheGuilds CacheFlags = 1 << iota
+	CacheChannels
+	CacheMembers
+	CacheRoles
+	CacheVoiceStates
+
+	CacheAll = CacheGuilds | CacheChannels | CacheMembers | CacheRoles | CacheVoiceStates
+)
+
+var cacheFlagNames = map[string]CacheFlags{
+	"guilds":       CacheGuilds,
+	"channels":     CacheChannels,
+	"members":      CacheMembers,
+	"roles":        CacheRoles,
+	"voice-states": CacheVoiceStates,
+}
+
+// ParseCacheFlags converts entity type names (e.g. from the config file) into cache flags.
+// No names at all means that everything is cached.
+func ParseCacheFlags(names []string) (CacheFlags, error) {
+	if len(names) == 0 {
+		return CacheAll, nil
+	}
+
+	var flags CacheFlags
+	for _, name := range names {
+		flag, ok := cacheFlagNames[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown cached entity type %s", name)
+		}
+
+		flags |= flag
+	}
+
+	return flags, nil
+}
+
+// State caches the entities received from the gateway. All lookup methods are safe for concurrent use. The returned
+// values share their slices and pointers with the cache, which never modifies them in place but replaces whole
+// entities, so they must be treated as read-only.
+type State struct {
+	mtx       sync.RWMutex
+	flags     CacheFlags
+	unmarshal func(data []byte, v any) error
+
+	// Guilds are stored without channels, members, roles and voice states, those are kept in their own maps.
+	guilds      map[string]object.Guild
+	channels    map[string]object.Channel
+	members     map[string]map[string]object.GuildMember
+	roles       map[string]map[string]object.Role
+	voiceStates map[string]map[string]object.VoiceState
+}
+
+// New creates the cache. The payloads are decoded with unmarshal, json.Unmarshal if it is nil.
+func New(flags CacheFlags, unmarshal func(data []byte, v any) error) *State {
+	if unmarshal == nil {
+		unmarshal = json.Unmarshal
+	}
+
+	return &State{
+		flags:       flags,
+		unmarshal:   unmarshal,
+		guilds:      make(map[string]object.Guild),
+		channels:    make(map[string]object.Channel),
+		members:     make(map[string]map[string]object.GuildMember),
+		roles:       make(map[string]map[string]object.Role),
+		voiceStates: make(map[string]map[string]object.VoiceState),
+	}
+}
+
+// Ingest updates the cache with the dispatch payload. Dispatches which do not affect the cache are ignored.
+func (s *State) Ingest(dispatch object.Dispatch, payload []byte) error {
+	switch dispatch {
+	case object.GuildCreateType, object.GuildUpdateType:
+		var guild object.Guild
+		err := s.unmarshal(payload, &guild)
+		if err != nil {
+			return err
+		}
+
+		s.setGuild(guild)
+	case object.GuildDeleteType:
+		var guild object.UnavailableGuild
+		err := s.unmarshal(payload, &guild)
+		if err != nil {
+			return err
+		}
+
+		// An unavailable guild is affected by an outage and will be created again once it is back.
+		if guild.Unavailable {
+			s.markGuildUnavailable(guild.ID)
+		} else {
+			s.deleteGuild(guild.ID)
+		}
+	case object.ChannelCreateType, object.ChannelUpdateType:
+		var channel object.Channel
+		err := s.unmarshal(payload, &channel)
+		if err != nil {
+			return err
+		}
+
+		s.setChannel(channel)
+	case object.ChannelDeleteType:
+		var channel object.Channel
+		err := s.unmarshal(payload, &channel)
+		if err != nil {
+			return err
+		}
+
+		s.deleteChannel(channel.ID)
+	case object.GuildMemberAddType:
+		var member object.GuildMemberAdd
+		err := s.unmarshal(payload, &member)
+		if err != nil {
+			return err
+		}
+
+		s.setMember(member.GuildID, member.GuildMember)
+	case object.GuildMemberUpdateType:
+		var member object.GuildMemberUpdate
+		err := s.unmarshal(payload, &member)
+		if err != nil {
+			return err
+		}
+
+		s.setMember(member.GuildID, member.GuildMember)
+	case object.GuildMemberRemoveType:
+		var member object.GuildMemberRemove
+		err := s.unmarshal(payload, &member)
+		if err != nil {
+			return err
+		}
+
+		s.deleteMember(member.GuildID, member.User.ID)
+	case object.GuildRoleCreateType, object.GuildRoleUpdateType:
+		var role object.GuildRole
+		err := s.unmarshal(payload, &role)
+		if err != nil {
+			return err
+		}
+
+		s.setRole(role.GuildID, role.Role)
+	case object.GuildRoleDeleteType:
+		var role object.GuildRoleDelete
+		err := s.unmarshal(payload, &role)
+		if err != nil {
+			return err
+		}
+
+		s.deleteRole(role.GuildID, role.RoleID)
+	case object.VoiceStateUpdateType:
+		var voiceState object.VoiceState
+		err := s.unmarshal(payload, &voiceState)
+		if err != nil {
+			return err
+		}
+
+		if voiceState.GuildID != nil {
+			s.setVoiceState(*voiceState.GuildID, voiceState)
+		}
+	}
+
+	return nil
+}
+
+func (s *State) Guild(guildID string) (object.Guild, bool) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	guild, ok := s.guilds[guildID]
+	return guild, ok
+}
+
+func (s *State) Guilds() []object.Guild {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	guilds := make([]object.Guild, 0, len(s.guilds))
+	for _, guild := range s.guilds {
+		guilds = append(guilds, guild)
+	}
+
+	return guilds
+}
+
+func (s *State) Channel(channelID string) (object.Channel, bool) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	channel, ok := s.channels[channelID]
+	return channel, ok
+}
+
+func (s *State) GuildChannels(guildID string) []object.Channel {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	var channels []object.Channel
+	for _, channel := range s.channels {
+		if channel.GuildID != nil && *channel.GuildID == guildID {
+			channels = append(channels, channel)
+		}
+	}
+
+	return channels
+}
+
+func (s *State) Member(guildID string, userID string) (object.GuildMember, bool) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	member, ok := s.members[guildID][userID]
+	return member, ok
+}
+
+func (s *State) Members(guildID string) []object.GuildMember {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	members := make([]object.GuildMember, 0, len(s.members[guildID]))
+	for _, member := range s.members[guildID] {
+		members = append(members, member)
+	}
+
+	return members
+}
+
+func (s *State) Role(guildID string, roleID string) (object.Role, bool) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	role, ok := s.roles[guildID][roleID]
+	return role, ok
+}
+
+func (s *State) Roles(guildID string) []object.Role {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	roles := make([]object.Role, 0, len(s.roles[guildID]))
+	for _, role := range s.roles[guildID] {
+		roles = append(roles, role)
+	}
+
+	return roles
+}
+
+func (s *State) VoiceState(guildID string, userID string) (object.VoiceState, bool) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	voiceState, ok := s.voiceStates[guildID][userID]
+	return voiceState, ok
+}
+
+func (s *State) VoiceStates(guildID string) []object.VoiceState {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	voiceStates := make([]object.VoiceState, 0, len(s.voiceStates[guildID]))
+	for _, voiceState := range s.voiceStates[guildID] {
+		voiceStates = append(voiceStates, voiceState)
+	}
+
+	return voiceStates
+}
+
+func (s *State) setGuild(guild object.Guild) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// GUILD_UPDATE does not carry the entities below, so they are only replaced when present.
+	if guild.Channels != nil && s.flags&CacheChannels != 0 {
+		for id, channel := range s.channels {
+			if channel.GuildID != nil && *channel.GuildID == guild.ID {
+				delete(s.channels, id)
+			}
+		}
+
+		for _, channel := range *guild.Channels {
+			// Channels embedded in GUILD_CREATE lack the guild_id field.
+			guildID := guild.ID
+			channel.GuildID = &guildID
+			s.channels[channel.ID] = channel
+		}
+	}
+
+	if guild.Members != nil && s.flags&CacheMembers != 0 {
+		members := make(map[string]object.GuildMember, len(*guild.Members))
+		for _, member := range *guild.Members {
+			if member.User != nil {
+				members[member.User.ID] = member
+			}
+		}
+
+		s.members[guild.ID] = members
+	}
+
+	if guild.Roles != nil && s.flags&CacheRoles != 0 {
+		roles := make(map[string]object.Role, len(guild.Roles))
+		for _, role := range guild.Roles {
+			roles[role.ID] = role
+		}
+
+		s.roles[guild.ID] = roles
+	}
+
+	if guild.VoiceStates != nil && s.flags&CacheVoiceStates != 0 {
+		voiceStates := make(map[string]object.VoiceState, len(*guild.VoiceStates))
+		for _, voiceState := range *guild.VoiceStates {
+			// Voice states embedded in GUILD_CREATE lack the guild_id field.
+			guildID := guild.ID
+			voiceState.GuildID = &guildID
+			voiceStates[voiceState.UserID] = voiceState
+		}
+
+		s.voiceStates[guild.ID] = voiceStates
+	}
+
+	if s.flags&CacheGuilds != 0 {
+		guild.Channels = nil
+		guild.Members = nil
+		guild.Roles = nil
+		guild.VoiceStates = nil
+		s.guilds[guild.ID] = guild
+	}
+}
+
+func (s *State) markGuildUnavailable(guildID string) {
+	if s.flags&CacheGuilds == 0 {
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	unavailable := true
+	guild := s.guilds[guildID]
+	guild.ID = guildID
+	guild.Unavailable = &unavailable
+	s.guilds[guildID] = guild
+}
+
+func (s *State) deleteGuild(guildID string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	delete(s.guilds, guildID)
+	delete(s.members, guildID)
+	delete(s.roles, guildID)
+	delete(s.voiceStates, guildID)
+
+	for id, channel := range s.channels {
+		if channel.GuildID != nil && *channel.GuildID == guildID {
+			delete(s.channels, id)
+		}
+	}
+}
+
+func (s *State) setChannel(channel object.Channel) {
+	if s.flags&CacheChannels == 0 {
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.channels[channel.ID] = channel
+}
+
+func (s *State) deleteChannel(channelID string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	delete(s.channels, channelID)
+}
+
+func (s *State) setMember(guildID string, member object.GuildMember) {
+	if s.flags&CacheMembers == 0 || member.User == nil {
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	members, ok := s.members[guildID]
+	if !ok {
+		members = make(map[string]object.GuildMember)
+		s.members[guildID] = members
+	}
+
+	members[member.User.ID] = member
+}
+
+func (s *State) deleteMember(guildID string, userID string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	delete(s.members[guildID], userID)
+}
+
+func (s *State) setRole(guildID string, role object.Role) {
+	if s.flags&CacheRoles == 0 {
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	roles, ok := s.roles[guildID]
+	if !ok {
+		roles = make(map[string]object.Role)
+		s.roles[guildID] = roles
+	}
+
+	roles[role.ID] = role
+}
+
+func (s *State) deleteRole(guildID string, roleID string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	delete(s.roles[guildID], roleID)
+}
+
+func (s *State) setVoiceState(guildID string, voiceState object.VoiceState) {
+	if s.flags&CacheVoiceStates == 0 {
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// A voice state without a channel means that the user has left the voice channel.
+	if voiceState.ChannelID == nil {
+		delete(s.voiceStates[guildID], voiceState.UserID)
+		return
+	}
+
+	voiceStates, ok := s.voiceStates[guildID]
+	if !ok {
+		voiceStates = make(map[string]object.VoiceState)
+		s.voiceStates[guildID] = voiceStates
+	}
+
+	voiceStates[voiceState.UserID] = voiceState
+}
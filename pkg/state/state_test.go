@@ -0,0 +1,44 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/bsponge/discordGopher/pkg/object"
+)
+
+func TestIngestGuildDelete(t *testing.T) {
+	s := New(CacheAll, nil)
+
+	err := s.Ingest(object.GuildCreateType, []byte(`{"id":"1","name":"guild","roles":[{"id":"2","name":"role"}],"channels":[{"id":"3","type":2}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Ingest(object.GuildDeleteType, []byte(`{"id":"1","unavailable":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	guild, ok := s.Guild("1")
+	if !ok || guild.Name != "guild" || guild.Unavailable == nil || !*guild.Unavailable {
+		t.Fatalf("expected the guild to be kept and marked unavailable, got %+v", guild)
+	}
+	if _, ok := s.Role("1", "2"); !ok {
+		t.Fatal("expected the roles of the unavailable guild to be kept")
+	}
+	if _, ok := s.Channel("3"); !ok {
+		t.Fatal("expected the channels of the unavailable guild to be kept")
+	}
+
+	err = s.Ingest(object.GuildDeleteType, []byte(`{"id":"1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := s.Guild("1"); ok {
+		t.Fatal("expected the guild to be removed")
+	}
+	if _, ok := s.Channel("3"); ok {
+		t.Fatal("expected the channels of the removed guild to be removed")
+	}
+}